@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,61 +18,398 @@ import (
 	"github.com/joho/godotenv"
 
 	"ads-go/internal/config"
+	"ads-go/internal/fixture"
+	"ads-go/internal/health"
 	appmw "ads-go/internal/http/middleware"
 	"ads-go/internal/http/routes"
+	"ads-go/internal/linkcheck"
+	"ads-go/internal/metrics"
+	"ads-go/internal/ratelimit"
+	"ads-go/internal/safeurl"
+	"ads-go/internal/spool"
 	mysqldb "ads-go/internal/storage/mysql"
+	"ads-go/internal/storage/mysql/migrate"
 	redisc "ads-go/internal/storage/redis"
+	"ads-go/internal/telemetry"
+	"ads-go/internal/tenant"
 )
 
+// version vem do link: go build -ldflags "-X main.version=v1.2.3" (senão, arquivo VERSION).
+var version string
+
 func main() {
 	// Carrega .env (best-effort)
 	_ = godotenv.Load(".env")
 	_ = godotenv.Load("../.env")
 
-	cfg := config.Load()
+	opts := config.Options{Overrides: config.Overrides{}}
+	checkConfig := flag.Bool("check-config", false, "valida a configuração, imprime os valores efetivos (segredos redigidos) e sai")
+	flag.StringVar(&opts.File, "config", os.Getenv("CONFIG_FILE"), "arquivo YAML de configuração (abaixo das envs e das flags)")
+	flag.Var(opts.Overrides, "set", "sobrescreve uma chave: -set KEY=valor (repetível; maior prioridade)")
+	devMode := flag.Bool("dev", false, "modo dev: catálogo, shortlinks e cliques em memória a partir de DEV_FIXTURE (= -set DEV_MODE=true)")
+	flag.Parse()
+	if *devMode {
+		opts.Overrides["DEV_MODE"] = "true"
+	}
+
+	cfg, err := config.Load(opts)
+	if *checkConfig {
+		cfg.Print(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "configuração OK")
+		return
+	}
+
+	// Logs JSON (slog); nível recarregável no SIGHUP
+	logLevel := new(slog.LevelVar)
+	if l, ok := telemetry.ParseLevel(cfg.LogLevel); ok {
+		logLevel.Set(l)
+	}
+	slog.SetDefault(telemetry.NewLogger(os.Stdout, telemetry.LogOptions{Level: logLevel, Format: cfg.LogFormat, RedactIPs: cfg.LogRedactIPs}))
+	if err != nil {
+		fatal("config inválida", "err", err)
+	}
+
+	// Subcomando: server [flags] migrate up|down [N]|status
+	if flag.Arg(0) == "migrate" {
+		os.Exit(runMigrate(cfg, flag.Args()[1:]))
+	}
+	if cfg.DevMode {
+		// nada de fora: sem Redis, snapshot, fila de cliques nem linkcheck; tenants vêm da fixture
+		cfg.RedisURL, cfg.RedisAddrs = "", nil
+		cfg.SnapshotFile, cfg.ClickQueueFile = "", ""
+		cfg.LinkCheckInterval = 0
+	}
+	slog.Info("iniciando", "env", cfg.Env, "version", health.Version(version))
 
-	// MySQL obrigatório
-	db, err := mysqldb.Open()
+	// Tracing: traceparent (W3C) sempre propagado; spans exportados conforme TRACE_EXPORTER
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), telemetry.TraceOptions{
+		Exporter:     cfg.TraceExporter,
+		OTLPEndpoint: cfg.TraceOTLPEndpoint,
+		Ratio:        cfg.TraceSampleRatio,
+		Routes:       cfg.TraceSampleRoutes,
+		Version:      health.Version(version),
+	})
 	if err != nil {
-		log.Fatalf("mysql open: %v", err)
+		fatal("tracing", "err", err)
+	}
+	conf := config.NewStore(cfg, opts)
+	conf.OnReload(func(c config.Config) {
+		if l, ok := telemetry.ParseLevel(c.LogLevel); ok {
+			logLevel.Set(l)
+		}
+	})
+
+	// Último estado bom (catálogo, shortlinks quentes, tenants): permite subir com o MySQL fora
+	snap := routes.NewLastGood(cfg.SnapshotMaxShorts)
+	if cfg.SnapshotFile != "" {
+		if err := snap.Load(cfg.SnapshotFile); err == nil {
+			slog.Info("snapshot carregado", "path", cfg.SnapshotFile, "saved_at", snap.LoadedAt())
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("snapshot ilegível (ignorado)", "path", cfg.SnapshotFile, "err", err)
+		}
 	}
-	if err := mysqldb.Ping(db); err != nil {
-		log.Fatalf("mysql ping: %v", err)
+
+	// MySQL obrigatório (primário), réplicas opcionais para leitura; no modo dev, a fixture em memória
+	var (
+		db    *mysqldb.DB // nil no modo dev
+		store routes.Store
+		fix   *fixture.Fixture
+	)
+	if cfg.DevMode {
+		if fix, err = fixture.Load(cfg.DevFixture); err != nil {
+			fatal("fixture do modo dev", "path", cfg.DevFixture, "err", err)
+		}
+		store = routes.NewMemoryStore(fix.Ads)
+		slog.Warn("modo dev: catálogo, shortlinks e cliques em memória (sem MySQL e sem Redis)", "fixture", cfg.DevFixture, "tenants", len(fix.Tenants), "ads", len(fix.Ads))
+	} else {
+		db = openMySQL(cfg, snap)
+		defer db.Close()
+		store = routes.NewMySQLStore(db)
 	}
 
 	// Redis OPCIONAL (usa a assinatura do pacote do projeto)
 	rdb, err := redisc.New(cfg)
 	if err != nil {
-		log.Printf("redis init: %v (seguindo sem redis)", err)
+		slog.Warn("redis init falhou (seguindo sem redis)", "err", err)
 		rdb = nil
 	} else if rdb != nil {
 		if err := rdb.Ping(context.Background()).Err(); err != nil {
-			log.Printf("redis indisponível: %v (seguindo sem redis)", err)
+			slog.Warn("redis indisponível (seguindo sem redis)", "err", err)
+			_ = rdb.Close()
 			rdb = nil
 		}
 	}
 
+	// Tarefas em background (param no shutdown)
+	bg, stopBg := context.WithCancel(context.Background())
+	defer stopBg()
+	if db != nil {
+		db.StartHealthCheck(bg, cfg.MySQLReplicaCheck)
+	}
+
+	// Registro de tenants (opcional: sem fonte, ficam os embutidos)
+	var tenantSrc tenant.Source
+	switch {
+	case cfg.DevMode:
+		tenantSrc = tenant.NewStaticSource(fix.Registry())
+	case cfg.TenantsSource == "mysql":
+		tenantSrc = tenant.NewMySQLSource(db.Primary()) // registro lê do primário: sem atraso de replicação
+	case cfg.TenantsSource == "file":
+		tenantSrc = tenant.NewFileSource(cfg.TenantsFile)
+	}
+	if tenantSrc != nil {
+		if n, err := tenant.Reload(bg, tenantSrc); err != nil {
+			if saved := snap.Tenants(); len(saved) > 0 {
+				_, _ = tenant.Reload(bg, tenant.NewStaticSource(saved))
+				slog.Error("tenants load falhou (seguindo com os do snapshot)", "err", err, "count", len(saved))
+			} else {
+				slog.Error("tenants load falhou (seguindo com os embutidos)", "err", err)
+			}
+		} else {
+			slog.Info("tenants carregados", "count", n, "source", cfg.TenantsSource)
+		}
+		tenant.StartReloader(bg, tenantSrc, func() time.Duration { return conf.Get().TenantsReload }, slog.Default())
+	}
+
+	// Validação de destinos (blocklist/allowlist recarregáveis)
+	guard, err := safeurl.New(cfg.DestBlocklistFile, cfg.DestAllowlist)
+	if err != nil {
+		fatal("blocklist de destinos inválida", "path", cfg.DestBlocklistFile, "err", err)
+	}
+	conf.OnReload(func(c config.Config) {
+		if err := guard.Reload(c.DestBlocklistFile, c.DestAllowlist); err != nil {
+			slog.Error("reload da blocklist de destinos falhou (mantendo a anterior)", "err", err)
+		}
+	})
+
+	// SIGHUP: recarrega config (só as chaves quentes) e tenants
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if res, err := conf.Reload(); err != nil {
+				slog.Error("SIGHUP: config inválida (mantendo a atual)", "err", err)
+			} else {
+				slog.Info("SIGHUP: config recarregada", "applied", res.Applied, "restart_pending", res.RestartPending)
+			}
+			if tenantSrc != nil {
+				if n, err := tenant.Reload(bg, tenantSrc); err != nil {
+					slog.Error("SIGHUP: tenants reload falhou (mantendo registro atual)", "err", err)
+				} else {
+					slog.Info("SIGHUP: tenants recarregados", "count", n)
+				}
+			}
+		}
+	}()
+
+	// Snapshot periódico do último estado bom
+	if cfg.SnapshotFile != "" {
+		snap.StartSnapshots(bg, cfg.SnapshotFile, cfg.SnapshotInterval)
+	}
+
+	// Fila em disco dos cliques que não chegaram ao MySQL; drenada quando ele volta
+	var clicks *spool.Spool
+	if cfg.ClickQueueFile != "" {
+		if clicks, err = spool.Open(cfg.ClickQueueFile, cfg.ClickQueueMax); err != nil {
+			fatal("fila de cliques", "path", cfg.ClickQueueFile, "err", err)
+		}
+		defer clicks.Close()
+		if n := clicks.Len(); n > 0 {
+			slog.Warn("cliques pendentes na fila em disco", "count", n)
+		}
+		clicks.Start(bg, cfg.ClickQueueRetry, store.Up, routes.ReplayClick(store))
+		metrics.RegisterQueueDepth(clicks.Len)
+	}
+
+	// Verificador de destinos (opcional)
+	var links *linkcheck.Checker
+	if cfg.LinkCheckInterval > 0 {
+		links = linkcheck.New(linkcheck.NewMySQLSource(db, tenant.IDs), linkcheck.Options{
+			Interval:     cfg.LinkCheckInterval,
+			Concurrency:  cfg.LinkCheckConcurrency,
+			HostInterval: cfg.LinkCheckHostInterval,
+			MaxRedirects: cfg.LinkCheckMaxRedirects,
+			Timeout:      cfg.LinkCheckTimeout,
+			Validate:     guard.Check,
+		})
+		links.Start(bg, slog.Default())
+	}
+
+	proxies, err := appmw.NewTrustedProxies(cfg.TrustedProxies, cfg.TrustedProxiesFile)
+	if err != nil {
+		fatal("trusted proxies", "err", err)
+	}
+
+	// Health: liveness não olha dependências; readiness checa MySQL (crítico), Redis e catálogo
+	hc := health.New(health.Version(version), 2*time.Second)
+	if db != nil {
+		hc.Add("mysql", true, func(ctx context.Context) (string, error) {
+			if err := db.Ping(ctx); err != nil {
+				if snap.Has() {
+					return "fora do ar: servindo do último estado bom", health.Degraded(err)
+				}
+				return "", err
+			}
+			return "primário", nil
+		})
+	} else {
+		hc.Add("store", true, func(ctx context.Context) (string, error) {
+			return "memória (modo dev: " + cfg.DevFixture + ")", nil
+		})
+	}
+	if clicks != nil {
+		hc.Add("events", false, func(ctx context.Context) (string, error) {
+			n := clicks.Len()
+			detail := fmt.Sprintf("%d na fila em disco", n)
+			if n > 0 {
+				return detail, errors.New("cliques aguardando o MySQL")
+			}
+			return detail, nil
+		})
+	}
+	if db != nil && len(cfg.MySQLReplicaDSNs) > 0 {
+		hc.Add("mysql_replicas", false, func(ctx context.Context) (string, error) {
+			ok, total := db.Replicas()
+			detail := fmt.Sprintf("%d/%d no rodízio", ok, total)
+			if ok == 0 {
+				return detail, errors.New("nenhuma réplica saudável: leituras no primário")
+			}
+			return detail, nil
+		})
+	}
+	if !cfg.DevMode {
+		hc.Add("redis", false, func(ctx context.Context) (string, error) {
+			if rdb == nil {
+				return "desligado", errors.New("sem redis: cache de shortlinks desativado")
+			}
+			return "", rdb.Ping(ctx).Err()
+		})
+	}
+	hc.Add("catalog", false, routes.CatalogCheck(store, time.Minute))
+
+	// Métricas: pools do MySQL e idade da última leitura do catálogo
+	if db != nil {
+		db.EachPool(metrics.RegisterDB)
+	}
+	metrics.RegisterAge("ads_catalog_last_read_age_seconds", "Segundos desde a última leitura bem-sucedida do catálogo (-1 = nunca).", routes.CatalogReadAt)
+
+	// Rate limit: GCRA no Redis (orçamento compartilhado entre as instâncias); sem Redis, por instância
+	limiter := ratelimit.New(rdb)
+	rateLimit := func(group string) func(http.Handler) http.Handler {
+		return appmw.RateLimit(limiter, group, func(group, scope string) (ratelimit.Limit, bool) {
+			c := conf.Get()
+			l, ok := c.RateLimits[group+"."+scope]
+			return ratelimit.Limit(l), ok && c.RateLimitEnabled
+		})
+	}
+
+	// API_KEY em uso; vazia (ninguém passa) se um reload deixou as credenciais fracas
+	apiKey := func() string {
+		c := conf.Get()
+		if c.AdminCredentialsError() != nil {
+			return ""
+		}
+		return c.APIKey
+	}
+
+	deps := routes.Deps{Cfg: cfg, Conf: conf, Rdb: rdb, DB: db, Store: store, Links: links, Guard: guard, Snap: snap, Clicks: clicks, RateLimit: rateLimit}
+
 	// Router
 	r := chi.NewRouter()
+	r.MethodNotAllowed(appmw.MethodNotAllowed) // 405 com o Allow da rota
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(appmw.Trace(r))
+	r.Use(appmw.Metrics())
+	r.Use(appmw.RealIP(proxies))
+	r.Use(appmw.AccessLog(func() float64 { return conf.Get().LogAccessSample }))
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(10 * time.Second))
-	r.Use(appmw.CORS(cfg.AllowedOrigins)) // <- assinatura correta
-	r.Use(appmw.OnlyGET())
+	r.Use(middleware.GetHead) // HEAD atendido pelo handler do GET
+
+	// Fora do grupo de tenant: o healthcheck do deploy bate em 127.0.0.1 (host de nenhum tenant)
+	// e "/_health/..." tem barra, então o "/{short}" nunca o captura.
+	r.Get("/_health/live", hc.Live)
+	r.Get("/_health/ready", hc.Ready)
+	// /metrics no listener principal (sem METRICS_ADDR) e os relatórios internos pedem X-API-Key;
+	// com API_KEY padrão ou curta nenhum dos dois é montado
+	if err := cfg.AdminCredentialsError(); err != nil {
+		slog.Error("/metrics e /_reports desligados: defina API_KEY e HMAC_SECRET fortes", "err", err)
+	} else {
+		if cfg.MetricsAddr == "" {
+			r.With(appmw.RequireAPIKey(apiKey)).Get("/metrics", metrics.Handler().ServeHTTP)
+		}
+		r.Route("/_reports", func(r chi.Router) {
+			r.Use(appmw.RequireAPIKey(apiKey))
+			routes.RegisterReports(r, deps)
+		})
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(appmw.Tenant(func() bool { return conf.Get().TenantStrict }))
+		r.Use(appmw.CORS(func() appmw.CORSOptions {
+			c := conf.Get()
+			return appmw.CORSOptions{
+				Extra:       c.AllowedOrigins,
+				Credentials: c.CORSCredentials,
+				Methods:     []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+				Headers:     c.CORSHeaders,
+				MaxAge:      c.CORSMaxAge,
+			}
+		}))
 
-	// Registro de rotas (assinatura correta do projeto)
-	routes.Register(r, cfg, rdb, db)
+		// Registro de rotas (assinatura correta do projeto); o rate limit vai por grupo de rotas,
+		// depois do Tenant e do CORS (o 429 sai com os headers de CORS). Rotas públicas registram
+		// GET (HEAD via GetHead) e OPTIONS (preflight, respondido pelo CORS): outro método recebe
+		// 405 + Allow.
+		routes.Register(r, deps)
+	})
+
+	// API de escrita: sem tenant por host nem CORS (é servidor a servidor); o rate limit vem
+	// antes da autenticação para frear tentativa de chave. Com chave/segredo padrão ou curtos
+	// (qualquer APP_ENV; só o modo dev libera) o /admin nem é montado.
+	if err := cfg.AdminCredentialsError(); err != nil {
+		slog.Error("/admin desligado: defina API_KEY e HMAC_SECRET fortes", "err", err)
+	} else {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(rateLimit("admin"))
+			r.Use(appmw.AdminAuth(func() (string, string) {
+				c := conf.Get()
+				if c.AdminCredentialsError() != nil {
+					return "", "" // reload enfraqueceu as credenciais: ninguém passa
+				}
+				return c.APIKey, c.HMACSecret
+			}, rdb))
+			routes.RegisterAdmin(r, deps)
+		})
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
 		Handler: r,
 	}
 
+	// Listener admin só com /metrics (bind interno; sem auth)
+	var admin *http.Server
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		admin = &http.Server{Addr: cfg.MetricsAddr, Handler: mux}
+		go func() {
+			slog.Info("metrics ouvindo", "addr", cfg.MetricsAddr)
+			if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("metrics server", "err", err)
+			}
+		}()
+	}
+
 	go func() {
-		log.Printf("listening on :%s", cfg.Port)
+		slog.Info("ouvindo", "addr", ":"+cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("server: %v", err)
+			fatal("server", "err", err)
 		}
 	}()
 
@@ -78,8 +418,65 @@ func main() {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 
+	stopBg()
+	if cfg.SnapshotFile != "" {
+		if err := snap.Save(cfg.SnapshotFile); err != nil {
+			slog.Error("snapshot: falha ao gravar no shutdown", "err", err)
+		}
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(ctx)
-	log.Println("shut down cleanly")
+	if admin != nil {
+		_ = admin.Shutdown(ctx)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("flush dos spans", "err", err)
+	}
+	slog.Info("encerrado")
+}
+
+// fatal loga em nível error e encerra o processo.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// openMySQL abre o primário e as réplicas. Com o primário fora no boot, só sobe se houver
+// snapshot para servir; com MIGRATE_ON_START, aplica as migrações pendentes.
+func openMySQL(cfg config.Config, snap *routes.LastGood) *mysqldb.DB {
+	db, err := mysqldb.Open(mysqldb.Options{
+		DSN:             cfg.MySQLDSN,
+		ReplicaDSNs:     cfg.MySQLReplicaDSNs,
+		MaxOpen:         cfg.MySQLMaxOpen,
+		MaxIdle:         cfg.MySQLMaxIdle,
+		ConnMaxLifetime: cfg.MySQLConnMaxLifetime,
+		ConnMaxIdleTime: cfg.MySQLConnMaxIdleTime,
+		ReadTimeout:     cfg.MySQLReadTimeout,
+		WriteTimeout:    cfg.MySQLWriteTimeout,
+	})
+	if err != nil {
+		fatal("mysql open", "err", err)
+	}
+
+	pctx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+	err = db.Ping(pctx)
+	cancelPing()
+	if err != nil {
+		if !snap.Has() {
+			fatal("mysql ping (sem snapshot para servir)", "err", err)
+		}
+		slog.Error("mysql fora do ar: servindo do snapshot e tentando reconectar", "err", err, "snapshot_saved_at", snap.LoadedAt())
+		return db
+	}
+	slog.Info("mysql conectado", "replicas", len(cfg.MySQLReplicaDSNs))
+	if cfg.MigrateOnStart {
+		// GET_LOCK: com as duas instâncias subindo juntas, uma migra e a outra espera o tempo que
+		// for (sem prazo: uma migração longa não derruba o boot da outra) e sobe sem nada pendente
+		done, err := migrate.Up(context.Background(), db.Primary())
+		if err != nil {
+			fatal("migrate up", "err", err, "applied", done)
+		}
+	}
+	return db
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"ads-go/internal/config"
+	mysqldb "ads-go/internal/storage/mysql"
+	"ads-go/internal/storage/mysql/migrate"
+)
+
+const migrateUsage = `uso: server [flags] migrate up|down [N]|status
+  up      aplica as migrações pendentes
+  down N  desfaz as N últimas aplicadas (padrão 1)
+  status  lista as migrações e quando foram aplicadas`
+
+// runMigrate executa o subcomando migrate no primário (MYSQL_DSN) e devolve o exit code.
+func runMigrate(cfg config.Config, args []string) int {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	db, err := mysqldb.Open(mysqldb.Options{DSN: cfg.MySQLDSN, MaxOpen: 2})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mysql:", err)
+		return 1
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		done, err := migrate.Up(ctx, db.Primary())
+		fmt.Printf("%d migração(ões) aplicada(s) %v\n", len(done), done)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		done, err := migrate.Down(ctx, db.Primary(), steps)
+		fmt.Printf("%d migração(ões) desfeita(s) %v\n", len(done), done)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "status":
+		states, err := migrate.Status(ctx, db.Primary())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSÃO\tNOME\tAPLICADA EM")
+		for _, s := range states {
+			at := "pendente"
+			if !s.AppliedAt.IsZero() {
+				at = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				at += " (não existe neste binário)"
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", s.Version, s.Name, at)
+		}
+		tw.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
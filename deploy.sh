@@ -5,7 +5,7 @@ APP_DIR="/projects/ads-go"
 RELEASES_DIR="$APP_DIR/releases"
 BIN_LINK="$APP_DIR/bin"
 WORKTREES_DIR="$APP_DIR/.worktrees"
-HEALTH_PATH="/ads?type=home"      # ajuste se quiser outro healthcheck
+HEALTH_PATH="/_health/ready"      # 503 se o MySQL não responde; Redis fora = "degraded" (200)
 TEST_URL_8089="http://127.0.0.1:8089${HEALTH_PATH}"
 TEST_URL_8088="http://127.0.0.1:8088${HEALTH_PATH}"
 
@@ -18,7 +18,7 @@ command -v go  >/dev/null || { echo "go não instalado"; exit 1; }
 mkdir -p "$RELEASES_DIR" "$WORKTREES_DIR"
 
 # 1) descobrir tag mais recente no repositório
-echo "[1/7] Buscando tags..."
+echo "[1/8] Buscando tags..."
 git fetch origin --tags --prune
 
 # tenta ordenar semver; se não houver, cai para a mais recente por data
@@ -50,29 +50,34 @@ fi
 # 3) preparar worktree para a tag (não mexe no diretório principal)
 WT_PATH="$WORKTREES_DIR/$LATEST_TAG"
 if [[ -d "$WT_PATH" ]]; then
-  echo "[2/7] Worktree já existe: $WT_PATH"
+  echo "[2/8] Worktree já existe: $WT_PATH"
 else
-  echo "[2/7] Criando worktree: $WT_PATH"
+  echo "[2/8] Criando worktree: $WT_PATH"
   git worktree add -f "$WT_PATH" "$LATEST_TAG"
 fi
 
 # 4) build para releases/<TAG>
 NEW_RELEASE="$RELEASES_DIR/$LATEST_TAG"
 mkdir -p "$NEW_RELEASE"
-echo "[3/7] Build da tag $LATEST_TAG..."
+echo "[3/8] Build da tag $LATEST_TAG..."
 pushd "$WT_PATH" >/dev/null
-GOFLAGS="-trimpath" CGO_ENABLED=0 go build -ldflags="-s -w" -o "$NEW_RELEASE/ads-go" ./cmd/server
+GOFLAGS="-trimpath" CGO_ENABLED=0 go build -ldflags="-s -w -X main.version=$LATEST_TAG" -o "$NEW_RELEASE/ads-go" ./cmd/server
 popd >/dev/null
 
 # grava a versão da release
 echo "$LATEST_TAG" > "$NEW_RELEASE/VERSION"
 
-# 5) troca atômica do symlink bin -> releases/<TAG>
-echo "[4/7] Apontando bin -> $NEW_RELEASE"
+# 5) migrações do schema com o binário novo, antes de qualquer instância reiniciar
+#    (compatíveis com a versão em produção: só adicionar; remover fica para a release seguinte)
+echo "[4/8] Migrações..."
+"$NEW_RELEASE/ads-go" migrate up
+
+# 6) troca atômica do symlink bin -> releases/<TAG>
+echo "[5/8] Apontando bin -> $NEW_RELEASE"
 ln -sfn "$NEW_RELEASE" "$BIN_LINK"
 
-# 6) restart sem downtime: 8089 -> testa -> 8088
-echo "[5/7] Reiniciando 8089..."
+# 7) restart sem downtime: 8089 -> testa -> 8088
+echo "[6/8] Reiniciando 8089..."
 sudo systemctl restart ads-go@8089
 sleep 2
 if curl -fsS "$TEST_URL_8089" >/dev/null; then
@@ -82,7 +87,7 @@ else
   exit 1
 fi
 
-echo "[6/7] Reiniciando 8088..."
+echo "[7/8] Reiniciando 8088..."
 sudo systemctl restart ads-go@8088
 sleep 2
 if curl -fsS "$TEST_URL_8088" >/dev/null; then
@@ -92,4 +97,4 @@ else
   exit 1
 fi
 
-echo "[7/7] Deploy concluído! Atualizado para a tag: $LATEST_TAG"
+echo "[8/8] Deploy concluído! Atualizado para a tag: $LATEST_TAG"
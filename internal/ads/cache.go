@@ -2,6 +2,7 @@ package ads
 
 import (
 	"context"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -32,16 +33,16 @@ func (c *Cache) Get(tenantID int) []Ad {
 }
 
 // StartRefresher atualiza o cache periodicamente a partir do repo (MySQL).
-func (c *Cache) StartRefresher(ctx context.Context, repo Repository, tenants []int, interval time.Duration, logger func(string, ...any)) {
+func (c *Cache) StartRefresher(ctx context.Context, repo Repository, tenants []int, interval time.Duration, logger *slog.Logger) {
 	refresh := func() {
 		for _, tid := range tenants {
 			list, err := repo.ActiveByTypes(ctx, tid, []int{1,2,3,4})
 			if err != nil {
-				if logger != nil { logger("ads refresh tenant=%d err=%v", tid, err) }
+				if logger != nil { logger.Error("ads refresh", "tenant", tid, "err", err) }
 				continue
 			}
 			c.Set(tid, list)
-			if logger != nil { logger("ads refresh tenant=%d ok items=%d", tid, len(list)) }
+			if logger != nil { logger.Debug("ads refresh ok", "tenant", tid, "items", len(list)) }
 		}
 	}
 	refresh()
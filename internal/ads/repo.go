@@ -4,4 +4,5 @@ import "context"
 
 type Repository interface {
 	ActiveByTypes(ctx context.Context, tenantID int, types []int) ([]Ad, error)
+	ActiveItems(ctx context.Context, tenantID int, pageTypes []int) ([]Item, error)
 }
@@ -28,3 +28,17 @@ func (r *memoryRepo) ActiveByTypes(ctx context.Context, tenantID int, types []in
 	}
 	return out, nil
 }
+
+// ActiveItems agrupa os anúncios fake de ActiveByTypes por código, no formato do front.
+func (r *memoryRepo) ActiveItems(ctx context.Context, tenantID int, pageTypes []int) ([]Item, error) {
+	list, err := r.ActiveByTypes(ctx, tenantID, pageTypes)
+	if err != nil { return nil, err }
+	out := make([]Item, 0, len(list))
+	for _, a := range list {
+		out = append(out, Item{
+			Code:  a.ID,
+			Types: map[int]TypeVariant{a.Type: {File: a.File, Extension: a.FileExt}},
+		})
+	}
+	return out, nil
+}
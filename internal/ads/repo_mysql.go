@@ -13,6 +13,21 @@ type mysqlRepo struct{ db *sql.DB }
 
 func NewMySQLRepo(db *sql.DB) Repository { return &mysqlRepo{db: db} }
 
+// ActiveByTypes achata ActiveItems em um Ad por (anúncio, tipo), só dos tipos pedidos (cache/service).
+func (r *mysqlRepo) ActiveByTypes(ctx context.Context, tenantID int, types []int) ([]Ad, error) {
+	items, err := r.ActiveItems(ctx, tenantID, types)
+	if err != nil { return nil, err }
+	out := make([]Ad, 0, len(items))
+	for _, it := range items {
+		for _, tp := range types {
+			v, ok := it.Types[tp]
+			if !ok { continue }
+			out = append(out, Ad{ID: it.Code, Type: tp, File: v.File, FileExt: v.Extension, Active: true})
+		}
+	}
+	return out, nil
+}
+
 // ActiveItems retorna os anúncios ativos do tenant já no formato esperado pelo front.
 func (r *mysqlRepo) ActiveItems(ctx context.Context, tenantID int, pageTypes []int) ([]Item, error) {
 	// Se quiser filtrar por "tipo de página" (1..4), use pageTypes; senão ignoramos e devolvemos todos ativos
@@ -10,3 +10,17 @@ type Ad struct {
 	FileExt   string `json:"file_ext"`
 	Active    bool   `json:"active"`
 }
+
+// TypeVariant é o criativo de um tipo de página (coluna JSON ads.types).
+type TypeVariant struct {
+	File      string `json:"file"`
+	Extension string `json:"extension"`
+}
+
+// Item é um anúncio no formato do front (Node).
+type Item struct {
+	Code        string              `json:"code"`
+	Description string              `json:"description,omitempty"`
+	Breackpoint int                 `json:"breackpoint"`
+	Types       map[int]TypeVariant `json:"types"`
+}
@@ -4,36 +4,444 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// Ambientes aceitos em APP_ENV.
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+)
+
+// Modos aceitos em REDIS_MODE.
+const (
+	RedisSingle   = "single"
+	RedisSentinel = "sentinel"
+	RedisCluster  = "cluster"
 )
 
 type Config struct {
+	Env            string // dev | staging | prod
 	Port           string
+	MetricsAddr    string // listener admin do /metrics (ex.: 127.0.0.1:9090); vazio = /metrics na porta principal com API_KEY
+	DevMode        bool   // tudo em memória a partir de DevFixture: sem MySQL, Redis, snapshot e fila de cliques
+	DevFixture     string // YAML ou JSON com tenants e anúncios (internal/fixture)
+
+	// Logs (slog)
+	LogLevel        string  // debug | info | warn | error
+	LogFormat       string  // json | text
+	LogAccessSample float64 // fração das requisições no access log (5xx e lentas sempre entram)
+	LogRedactIPs    bool
+
+	// Tracing (OpenTelemetry)
+	TraceExporter     string             // none | stdout | otlp
+	TraceOTLPEndpoint string             // collector OTLP/HTTP (host:porta)
+	TraceSampleRatio  float64            // amostragem padrão
+	TraceSampleRoutes map[string]float64 // por rota do chi: "/_health/ready=0,/{short}=0.05"
 	APIKey         string
-	AllowedOrigins []string
+	AllowedOrigins []string // origens extras aceitas em todos os tenants (o normal é cada tenant só aceitar as suas)
 	HMACSecret     string
+	MySQLDSN       string
 	RedisURL       string
 	RecentN        int
+
+	// MySQL: primário (MYSQL_DSN) + réplicas de leitura opcionais
+	MySQLReplicaDSNs      []string
+	MySQLMaxOpen          int
+	MySQLMaxIdle          int
+	MySQLConnMaxLifetime  time.Duration
+	MySQLConnMaxIdleTime  time.Duration
+	MySQLReadTimeout      time.Duration // por consulta
+	MySQLWriteTimeout     time.Duration
+	MySQLReplicaCheck     time.Duration // intervalo do health check (réplicas e reconexão do primário)
+	MigrateOnStart        bool          // aplica as migrações pendentes no boot (senão: server migrate up)
+
+	// MySQL fora do ar: último estado bom em disco e fila de cliques
+	SnapshotFile      string // "" = sem snapshot (MySQL fora no boot = não sobe)
+	SnapshotInterval  time.Duration
+	SnapshotMaxShorts int // shortlinks quentes guardados
+	ClickQueueFile    string // "" = clique sem MySQL é descartado
+	ClickQueueMax     int
+	ClickQueueRetry   time.Duration
+
+	// Redis (opcional): REDIS_URL ou REDIS_ADDRS; modo single, sentinel ou cluster
+	RedisMode             string
+	RedisAddrs            []string // host:porta; no sentinel, os sentinels (REDIS_ADDR antigo é aceito)
+	RedisUsername         string
+	RedisPassword         string
+	RedisDB               int
+	RedisMasterName       string // sentinel
+	RedisSentinelPassword string
+	RedisPoolSize         int // 0 = padrão do go-redis (10 por CPU)
+	RedisMinIdle          int
+	RedisDialTimeout      time.Duration
+	RedisReadTimeout      time.Duration
+	RedisWriteTimeout     time.Duration
+	RedisTLS              bool
+	RedisTLSInsecure      bool
+	RedisTLSServerName    string
+	RedisTLSCAFile        string
+
+	// Validação de destinos de redirect (ver internal/safeurl)
+	DestBlocklistFile string
+	DestAllowlist     map[int][]string // tenantID -> domínios permitidos (vazio = qualquer um não bloqueado)
+
+	// Verificador de destinos em background (ver internal/linkcheck); intervalo 0 (padrão) desliga
+	LinkCheckInterval     time.Duration
+	LinkCheckConcurrency  int
+	LinkCheckHostInterval time.Duration
+	LinkCheckMaxRedirects int
+	LinkCheckTimeout      time.Duration
+	LinkCheckFailAfter    time.Duration // falhando há mais que isso = aparece no relatório
+
+	// Registro de tenants: "" (embutido), "mysql" (tabela tenants) ou "file" (JSON em TENANTS_FILE)
+	TenantsSource string
+	TenantsFile   string
+	TenantsReload time.Duration // 0 = só no boot e no SIGHUP
+	TenantStrict  bool          // host desconhecido = 421 (senão cai no tenant padrão)
+
+	// Proxies confiáveis: só deles aceitamos X-Forwarded-*/CF-Connecting-IP
+	TrustedProxies     []string // CIDRs ou IPs
+	TrustedProxiesFile string   // um CIDR por linha (ex.: faixas da Cloudflare)
+
+	// Rate limit por grupo de rotas ("ads", "short", "qr", "admin") e escopo ("ip", "tenant")
+	RateLimitEnabled bool
+	RateLimits       map[string]RateLimit // "grupo.escopo"
+
+	// CORS (as origens vêm do tenant; ver tenant.AllowedOrigins)
+	CORSCredentials bool
+	CORSMaxAge      time.Duration
+	CORSHeaders     []string
+
+	// valores efetivos por chave, na ordem de leitura (para --check-config)
+	settings []Setting
+}
+
+// RateLimit: Rate requisições a cada Per, com rajada de até Burst.
+type RateLimit struct {
+	Rate  int
+	Per   time.Duration
+	Burst int
+}
+
+// grupos de rotas e escopos aceitos em RATELIMITS
+var (
+	rateLimitGroups = map[string]bool{"ads": true, "short": true, "qr": true, "admin": true}
+	rateLimitScopes = map[string]bool{"ip": true, "tenant": true}
+)
+
+// Setting é uma chave de configuração com o valor efetivo (Secret = não imprimir)
+// e a camada de onde veio: default, file, env ou flag.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// layer é uma fonte de valores; a primeira camada que tiver a chave (não vazia) vence.
+type layer struct {
+	name string
+	get  func(string) (string, bool)
+}
+
+// loader lê as chaves, registra o valor efetivo e acumula erros de formato.
+type loader struct {
+	layers   []layer
+	settings []Setting
+	errs     ValidationError
+	src      string // camada do último raw()
+}
+
+func (l *loader) raw(key string) string {
+	for _, ly := range l.layers {
+		if v, ok := ly.get(key); ok && strings.TrimSpace(v) != "" {
+			l.src = ly.name
+			return strings.TrimSpace(v)
+		}
+	}
+	l.src = "default"
+	return ""
+}
+
+func (l *loader) record(key, val string, secret bool) {
+	l.settings = append(l.settings, Setting{Key: key, Value: val, Source: l.src, Secret: secret})
+}
+
+func (l *loader) str(key, def string) string {
+	v := l.raw(key)
+	if v == "" { v = def }
+	l.record(key, v, false)
+	return v
+}
+
+func (l *loader) secret(key, def string) string {
+	v := l.raw(key)
+	if v == "" { v = def }
+	l.record(key, v, true)
+	return v
+}
+
+func (l *loader) list(key string, def []string) []string { return l.listOf(key, def, false) }
+
+// secretList: lista de segredos (ex.: DSNs de réplicas), redigida no --check-config.
+func (l *loader) secretList(key string) []string { return l.listOf(key, nil, true) }
+
+func (l *loader) listOf(key string, def []string, secret bool) []string {
+	v := l.raw(key)
+	out := def
+	if v != "" {
+		out = []string{}
+		for _, p := range strings.Split(v, ",") { p = strings.TrimSpace(p); if p != "" { out = append(out, p) } }
+	}
+	l.record(key, strings.Join(out, ","), secret)
+	return out
+}
+
+func (l *loader) int(key string, def int) int {
+	v := l.raw(key)
+	n := def
+	if v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil {
+			l.errs.add(key, v, "inteiro inválido")
+			n = def
+		}
+	}
+	l.record(key, strconv.Itoa(n), false)
+	return n
+}
+
+func (l *loader) float(key string, def float64) float64 {
+	v := l.raw(key)
+	f := def
+	if v != "" {
+		var err error
+		if f, err = strconv.ParseFloat(v, 64); err != nil {
+			l.errs.add(key, v, "número inválido")
+			f = def
+		}
+	}
+	l.record(key, strconv.FormatFloat(f, 'g', -1, 64), false)
+	return f
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v := l.raw(key)
+	b := def
+	if v != "" {
+		var err error
+		if b, err = strconv.ParseBool(v); err != nil {
+			l.errs.add(key, v, "booleano inválido (true/false)")
+			b = def
+		}
+	}
+	l.record(key, strconv.FormatBool(b), false)
+	return b
 }
 
-func get(key, def string) string { v := strings.TrimSpace(os.Getenv(key)); if v == "" { return def }; return v }
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v := l.raw(key)
+	d := def
+	if v != "" {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			l.errs.add(key, v, "duração inválida (ex.: 30s, 5m, 1h)")
+			d = def
+		}
+	}
+	l.record(key, d.String(), false)
+	return d
+}
+
+// ratios lê "chave=0.5,outra=0" -> {chave:0.5, outra:0}
+func (l *loader) ratios(key, def string) map[string]float64 {
+	v := l.raw(key)
+	if v == "" { v = def }
+	out := map[string]float64{}
+	for _, p := range strings.Split(v, ",") {
+		if strings.TrimSpace(p) == "" { continue }
+		k, r, ok := strings.Cut(p, "=")
+		f, err := strconv.ParseFloat(strings.TrimSpace(r), 64)
+		if !ok || err != nil || f < 0 || f > 1 {
+			l.errs.add(key, p, `formato esperado "rota=0..1,rota=0..1"`)
+			continue
+		}
+		out[strings.TrimSpace(k)] = f
+	}
+	l.record(key, v, false)
+	return out
+}
+
+// rateLimits lê "short.ip=120/m:40,short.tenant=30000/m" -> {"short.ip": {120 1m 40}, ...}.
+// Unidades s, m, h; sem ":burst", a rajada é o orçamento do período inteiro.
+func (l *loader) rateLimits(key, def string) map[string]RateLimit {
+	v := l.raw(key)
+	if v == "" { v = def }
+	out := map[string]RateLimit{}
+	per := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	for _, p := range strings.Split(v, ",") {
+		if strings.TrimSpace(p) == "" { continue }
+		k, spec, _ := strings.Cut(p, "=")
+		k = strings.ToLower(strings.TrimSpace(k))
+		grp, scope, _ := strings.Cut(k, ".")
+		spec, burst, hasBurst := strings.Cut(strings.TrimSpace(spec), ":")
+		rate, unit, _ := strings.Cut(spec, "/")
+		lim := RateLimit{Per: per[strings.TrimSpace(unit)]}
+		var err error
+		lim.Rate, err = strconv.Atoi(strings.TrimSpace(rate))
+		lim.Burst = lim.Rate
+		if err == nil && hasBurst { lim.Burst, err = strconv.Atoi(strings.TrimSpace(burst)) }
+		if !rateLimitGroups[grp] || !rateLimitScopes[scope] || err != nil || lim.Per == 0 || lim.Rate <= 0 || lim.Burst <= 0 {
+			l.errs.add(key, p, `formato esperado "grupo.escopo=N/s|m|h[:rajada]" (grupos: ads, short, qr, admin; escopos: ip, tenant)`)
+			continue
+		}
+		out[k] = lim
+	}
+	l.record(key, v, false)
+	return out
+}
 
-func parseOrigins() []string {
-	v := strings.TrimSpace(os.Getenv("ALLOWED_ORIGINS"))
-	if v == "" { return []string{"https://conexaoguarulhos.com.br", "https://www.conexaoguarulhos.com.br", "https://gazetadeosasco.com.br", "https://www.gazetadeosasco.com.br"} }
-	parts := strings.Split(v, ",")
-	out := make([]string, 0, len(parts))
-	for _, p := range parts { p = strings.TrimSpace(p); if p != "" { out = append(out, p) } }
+// tenantDomains lê "1:a.com,b.com;2:c.com" -> {1:[a.com b.com], 2:[c.com]}
+func (l *loader) tenantDomains(key string) map[int][]string {
+	v := l.raw(key)
+	out := map[int][]string{}
+	for _, grp := range strings.Split(v, ";") {
+		if strings.TrimSpace(grp) == "" { continue }
+		id, doms, ok := strings.Cut(grp, ":")
+		tid, err := strconv.Atoi(strings.TrimSpace(id))
+		if !ok || err != nil {
+			l.errs.add(key, grp, `formato esperado "id:dom1,dom2;id:dom3"`)
+			continue
+		}
+		for _, d := range strings.Split(doms, ",") {
+			if d = strings.TrimSpace(d); d != "" { out[tid] = append(out[tid], d) }
+		}
+	}
+	l.record(key, v, false)
 	return out
 }
 
-func Load() Config {
-	recentN, _ := strconv.Atoi(get("RECENT_N", "5"))
-	return Config{
-		Port:           get("PORT", "8080"),
-		APIKey:         get("API_KEY", "changeme"),
-		AllowedOrigins: parseOrigins(),
-		HMACSecret:     get("HMAC_SECRET", "super-secret"),
-		RedisURL:       os.Getenv("REDIS_URL"),
-		RecentN:        recentN,
+// Options diz de onde carregar além das variáveis de ambiente.
+type Options struct {
+	File      string    // arquivo YAML (opcional); chaves = nomes das envs, em minúsculas ou não
+	Overrides Overrides // flags -set KEY=valor (maior prioridade)
+}
+
+// Load lê a configuração em camadas — defaults < arquivo < ambiente < flags — e valida.
+// Em erro, devolve a Config mesmo assim (com defaults nos campos inválidos)
+// e um ValidationError com todos os problemas.
+func Load(opts Options) (Config, error) {
+	layers := []layer{{"flag", opts.Overrides.get}, {"env", os.LookupEnv}}
+	var fileKeys map[string]string
+	if opts.File != "" {
+		var err error
+		if fileKeys, err = readFile(opts.File); err != nil {
+			return Config{}, ValidationError{{Key: "CONFIG_FILE", Value: opts.File, Msg: err.Error()}}
+		}
+		layers = append(layers, layer{"file", func(k string) (string, bool) { v, ok := fileKeys[k]; return v, ok }})
+	}
+
+	cfg, errs := load(layers)
+	// chave desconhecida no arquivo costuma ser erro de digitação
+	known := map[string]bool{}
+	for _, s := range cfg.settings { known[s.Key] = true }
+	for k := range fileKeys {
+		if !known[k] { errs.add(strings.ToLower(k), "", "chave desconhecida em "+opts.File) }
+	}
+	if len(errs) > 0 { return cfg, errs }
+	return cfg, nil
+}
+
+func load(layers []layer) (Config, ValidationError) {
+	l := &loader{layers: layers}
+	cfg := Config{
+		Env:            strings.ToLower(l.str("APP_ENV", EnvDev)),
+		Port:           l.str("PORT", "8080"),
+		MetricsAddr:    l.str("METRICS_ADDR", ""),
+		DevMode:        l.bool("DEV_MODE", false),
+		DevFixture:     l.str("DEV_FIXTURE", "dev/fixture.yaml"),
+
+		LogLevel:        l.str("LOG_LEVEL", "info"),
+		LogFormat:       l.str("LOG_FORMAT", "json"),
+		LogAccessSample: l.float("LOG_ACCESS_SAMPLE", 0.1),
+		LogRedactIPs:    l.bool("LOG_REDACT_IPS", true),
+
+		TraceExporter:     l.str("TRACE_EXPORTER", "none"),
+		TraceOTLPEndpoint: l.str("TRACE_OTLP_ENDPOINT", "localhost:4318"),
+		TraceSampleRatio:  l.float("TRACE_SAMPLE_RATIO", 0.1),
+		TraceSampleRoutes: l.ratios("TRACE_SAMPLE_ROUTES", "/_health/live=0,/_health/ready=0,/metrics=0"),
+		APIKey:         l.secret("API_KEY", defaultAPIKey),
+		AllowedOrigins: l.list("ALLOWED_ORIGINS", nil),
+		HMACSecret:     l.secret("HMAC_SECRET", defaultHMACSecret),
+		MySQLDSN:       l.secret("MYSQL_DSN", ""),
+		RedisURL:       l.secret("REDIS_URL", ""),
+		RecentN:        l.int("RECENT_N", 5),
+
+		MySQLReplicaDSNs:     l.secretList("MYSQL_REPLICA_DSNS"),
+		MySQLMaxOpen:         l.int("MYSQL_MAX_OPEN", 25),
+		MySQLMaxIdle:         l.int("MYSQL_MAX_IDLE", 10),
+		MySQLConnMaxLifetime: l.duration("MYSQL_CONN_MAX_LIFETIME", 5*time.Minute),
+		MySQLConnMaxIdleTime: l.duration("MYSQL_CONN_MAX_IDLE_TIME", time.Minute),
+		MySQLReadTimeout:     l.duration("MYSQL_READ_TIMEOUT", 2*time.Second),
+		MySQLWriteTimeout:    l.duration("MYSQL_WRITE_TIMEOUT", 2*time.Second),
+		MySQLReplicaCheck:    l.duration("MYSQL_REPLICA_CHECK", 5*time.Second),
+		MigrateOnStart:       l.bool("MIGRATE_ON_START", false),
+
+		SnapshotFile:      l.str("SNAPSHOT_FILE", "data/snapshot-{port}.json"),
+		SnapshotInterval:  l.duration("SNAPSHOT_INTERVAL", time.Minute),
+		SnapshotMaxShorts: l.int("SNAPSHOT_MAX_SHORTS", 5000),
+		ClickQueueFile:    l.str("CLICK_QUEUE_FILE", "data/clicks-{port}.queue"),
+		ClickQueueMax:     l.int("CLICK_QUEUE_MAX", 1000000),
+		ClickQueueRetry:   l.duration("CLICK_QUEUE_RETRY", 10*time.Second),
+
+		RedisMode:             strings.ToLower(l.str("REDIS_MODE", RedisSingle)),
+		RedisAddrs:            l.list("REDIS_ADDRS", l.list("REDIS_ADDR", nil)), // REDIS_ADDR: nome antigo, vale sem REDIS_ADDRS
+		RedisUsername:         l.str("REDIS_USERNAME", ""),
+		RedisPassword:         l.secret("REDIS_PASSWORD", ""),
+		RedisDB:               l.int("REDIS_DB", 0),
+		RedisMasterName:       l.str("REDIS_MASTER_NAME", ""),
+		RedisSentinelPassword: l.secret("REDIS_SENTINEL_PASSWORD", ""),
+		RedisPoolSize:         l.int("REDIS_POOL_SIZE", 0),
+		RedisMinIdle:          l.int("REDIS_MIN_IDLE", 0),
+		RedisDialTimeout:      l.duration("REDIS_DIAL_TIMEOUT", 2*time.Second),
+		RedisReadTimeout:      l.duration("REDIS_READ_TIMEOUT", 500*time.Millisecond),
+		RedisWriteTimeout:     l.duration("REDIS_WRITE_TIMEOUT", 500*time.Millisecond),
+		RedisTLS:              l.bool("REDIS_TLS", false),
+		RedisTLSInsecure:      l.bool("REDIS_TLS_INSECURE", false),
+		RedisTLSServerName:    l.str("REDIS_TLS_SERVER_NAME", ""),
+		RedisTLSCAFile:        l.str("REDIS_TLS_CA_FILE", ""),
+
+		DestBlocklistFile: l.str("DEST_BLOCKLIST_FILE", ""),
+		DestAllowlist:     l.tenantDomains("DEST_ALLOWLIST"),
+
+		LinkCheckInterval:     l.duration("LINKCHECK_INTERVAL", 0),
+		LinkCheckConcurrency:  l.int("LINKCHECK_CONCURRENCY", 8),
+		LinkCheckHostInterval: l.duration("LINKCHECK_HOST_INTERVAL", 2*time.Second),
+		LinkCheckMaxRedirects: l.int("LINKCHECK_MAX_REDIRECTS", 5),
+		LinkCheckTimeout:      l.duration("LINKCHECK_TIMEOUT", 10*time.Second),
+		LinkCheckFailAfter:    l.duration("LINKCHECK_FAIL_AFTER", 24*time.Hour),
+
+		TenantsSource: strings.ToLower(l.str("TENANTS_SOURCE", "")),
+		TenantsFile:   l.str("TENANTS_FILE", "tenants.json"),
+		TenantsReload: l.duration("TENANTS_RELOAD", 5*time.Minute),
+		TenantStrict:  l.bool("TENANT_STRICT", false),
+
+		RateLimitEnabled: l.bool("RATELIMIT_ENABLED", true),
+		RateLimits:       l.rateLimits("RATELIMITS", "ads.ip=600/m:120,short.ip=120/m:40,short.tenant=30000/m:5000,qr.ip=30/m:10,admin.ip=120/m:30"),
+
+		TrustedProxies:     l.list("TRUSTED_PROXIES", []string{"127.0.0.1/32", "::1/128"}),
+		TrustedProxiesFile: l.str("TRUSTED_PROXIES_FILE", ""),
+
+		CORSCredentials: l.bool("CORS_CREDENTIALS", true),
+		CORSMaxAge:      l.duration("CORS_MAX_AGE", 10*time.Minute),
+		CORSHeaders:     l.list("CORS_HEADERS", []string{"Content-Type", "Authorization"}),
 	}
+	cfg.settings = l.settings
+	// arquivos locais por instância (o deploy roda 8088 e 8089 no mesmo diretório)
+	cfg.SnapshotFile = strings.ReplaceAll(cfg.SnapshotFile, "{port}", cfg.Port)
+	cfg.ClickQueueFile = strings.ReplaceAll(cfg.ClickQueueFile, "{port}", cfg.Port)
+
+	return cfg, append(l.errs, cfg.validate()...)
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readFile lê o YAML de configuração. Formato plano, mesmas chaves das envs:
+//
+//	port: 8080
+//	allowed_origins: [http://localhost:3000]
+//	cors_max_age: 10m
+//
+// Listas viram "a,b,c" (como nas envs). Chaves são normalizadas para maiúsculas.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil { return nil, err }
+	var raw map[string]any
+	if err := yaml.Unmarshal(b, &raw); err != nil { return nil, err }
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		key := strings.ToUpper(strings.TrimSpace(k))
+		switch x := v.(type) {
+		case nil:
+			out[key] = ""
+		case []any:
+			parts := make([]string, len(x))
+			for i, p := range x { parts[i] = fmt.Sprint(p) }
+			out[key] = strings.Join(parts, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("%s: use valor simples ou lista, não objeto", k)
+		default:
+			out[key] = fmt.Sprint(x)
+		}
+	}
+	return out, nil
+}
+
+// Overrides são os -set KEY=valor da linha de comando (implementa flag.Value).
+type Overrides map[string]string
+
+func (o Overrides) get(k string) (string, bool) { v, ok := o[k]; return v, ok }
+
+func (o Overrides) String() string {
+	keys := make([]string, 0, len(o))
+	for k := range o { keys = append(keys, k) }
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func (o Overrides) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(k) == "" { return fmt.Errorf("use KEY=valor, recebido %q", s) }
+	o[strings.ToUpper(strings.TrimSpace(k))] = v
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// hotKeys são as chaves aplicadas no SIGHUP sem reiniciar; as demais só valem no próximo start.
+var hotKeys = map[string]bool{
+	"ALLOWED_ORIGINS": true, "CORS_CREDENTIALS": true, "CORS_MAX_AGE": true, "CORS_HEADERS": true,
+	"TENANT_STRICT": true, "DEST_BLOCKLIST_FILE": true, "DEST_ALLOWLIST": true,
+	"LINKCHECK_FAIL_AFTER": true, "TENANTS_RELOAD": true,
+	"LOG_LEVEL": true, "LOG_ACCESS_SAMPLE": true,
+	"RATELIMIT_ENABLED": true, "RATELIMITS": true,
+}
+
+// applyHot copia para c os campos das hotKeys vindos de n.
+func (c Config) applyHot(n Config) Config {
+	c.AllowedOrigins, c.CORSCredentials, c.CORSMaxAge, c.CORSHeaders = n.AllowedOrigins, n.CORSCredentials, n.CORSMaxAge, n.CORSHeaders
+	c.TenantStrict = n.TenantStrict
+	c.DestBlocklistFile, c.DestAllowlist = n.DestBlocklistFile, n.DestAllowlist
+	c.LinkCheckFailAfter = n.LinkCheckFailAfter
+	c.TenantsReload = n.TenantsReload
+	c.LogLevel, c.LogAccessSample = n.LogLevel, n.LogAccessSample
+	c.RateLimitEnabled, c.RateLimits = n.RateLimitEnabled, n.RateLimits
+
+	byKey := map[string]Setting{}
+	for _, s := range n.settings { byKey[s.Key] = s }
+	settings := make([]Setting, len(c.settings))
+	for i, s := range c.settings {
+		if ns, ok := byKey[s.Key]; ok && hotKeys[s.Key] { s = ns }
+		settings[i] = s
+	}
+	c.settings = settings
+	return c
+}
+
+// Store guarda a configuração atual; leituras (Get) são sem lock.
+type Store struct {
+	opts  Options
+	cur   atomic.Pointer[Config]
+	mu    sync.Mutex // serializa Reload
+	hooks []func(Config)
+}
+
+func NewStore(cfg Config, opts Options) *Store {
+	s := &Store{opts: opts}
+	s.cur.Store(&cfg)
+	return s
+}
+
+// Get devolve a configuração atual (não altere o valor retornado).
+func (s *Store) Get() *Config { return s.cur.Load() }
+
+// OnReload registra uma função chamada após cada reload aplicado (ex.: recarregar a blocklist).
+// Registre antes de começar a recarregar.
+func (s *Store) OnReload(fn func(Config)) { s.hooks = append(s.hooks, fn) }
+
+// ReloadResult resume um reload: chaves aplicadas e chaves alteradas que exigem restart.
+type ReloadResult struct {
+	Applied        []string
+	RestartPending []string
+}
+
+// Reload relê arquivo/ambiente, valida e aplica só as hotKeys.
+// Se a nova configuração for inválida, nada muda e o erro é devolvido.
+func (s *Store) Reload() (ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := Load(s.opts)
+	if err != nil { return ReloadResult{}, err }
+
+	cur := s.cur.Load()
+	old := map[string]string{}
+	for _, st := range cur.settings { old[st.Key] = st.Value }
+	var res ReloadResult
+	for _, st := range next.settings {
+		if old[st.Key] == st.Value { continue }
+		if hotKeys[st.Key] {
+			res.Applied = append(res.Applied, st.Key)
+		} else {
+			res.RestartPending = append(res.RestartPending, st.Key)
+		}
+	}
+	sort.Strings(res.Applied)
+	sort.Strings(res.RestartPending)
+	if len(res.Applied) == 0 { return res, nil }
+
+	applied := cur.applyHot(next)
+	s.cur.Store(&applied)
+	for _, fn := range s.hooks { fn(applied) }
+	return res, nil
+}
@@ -0,0 +1,102 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"ads-go/internal/tenant"
+)
+
+// Fixture é a massa de dados do modo dev: tenants e anúncios/shortlinks servidos da memória.
+// Arquivo YAML ou JSON (pela extensão), ex.: dev/fixture.yaml.
+type Fixture struct {
+	Tenants []Tenant `json:"tenants" yaml:"tenants"`
+	Ads     []Ad     `json:"ads" yaml:"ads"`
+}
+
+// Tenant tem os mesmos campos do TENANTS_FILE.
+type Tenant struct {
+	ID       int      `json:"id" yaml:"id"`
+	Portal   string   `json:"portal" yaml:"portal"`
+	Aliases  []string `json:"aliases" yaml:"aliases"`
+	AdsURL   string   `json:"ads_url" yaml:"ads_url"`
+	Static   string   `json:"static" yaml:"static"`
+	Timezone string   `json:"timezone" yaml:"timezone"`
+	Origins  []string `json:"cors_origins" yaml:"cors_origins"`
+}
+
+// Ad é uma linha de ads (anúncio e shortlink), com os nomes de coluna do MySQL.
+type Ad struct {
+	Tenant      int                 `json:"tenant" yaml:"tenant"`
+	Code        string              `json:"code" yaml:"code"`
+	UUID        string              `json:"uuid" yaml:"uuid"` // vazio = "dev-<tenant>-<code>"
+	Description string              `json:"description" yaml:"description"`
+	Redirect    string              `json:"redirect" yaml:"redirect"`
+	Status      *int                `json:"status" yaml:"status"` // ausente = 1 (ativo)
+	Breackpoint int                 `json:"breackpoint" yaml:"breackpoint"`
+	Types       map[string]Creative `json:"types" yaml:"types"` // tipo de página -> criativo
+	Variants    []Variant           `json:"variants" yaml:"variants"`
+	StartedAt   *time.Time          `json:"started_at" yaml:"started_at"`
+	ValidateAt  *time.Time          `json:"validate_at" yaml:"validate_at"`
+	Deleted     bool                `json:"deleted" yaml:"deleted"`
+}
+
+type Creative struct {
+	File      string `json:"file" yaml:"file"`
+	Extension string `json:"extension" yaml:"extension"`
+}
+
+type Variant struct {
+	Key    string `json:"key" yaml:"key"`
+	URL    string `json:"url" yaml:"url"`
+	Weight int    `json:"weight" yaml:"weight"`
+}
+
+// Active diz se o anúncio entra no catálogo em now (mesmas regras da consulta no MySQL).
+func (a Ad) Active(now time.Time) bool {
+	return !a.Deleted && (a.Status == nil || *a.Status == 1) &&
+		(a.StartedAt == nil || !a.StartedAt.After(now)) && (a.ValidateAt == nil || a.ValidateAt.After(now))
+}
+
+// Load lê e confere o arquivo (tenant dos anúncios existe, código único por tenant).
+func Load(path string) (*Fixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil { return nil, err }
+	var f Fixture
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(b, &f)
+	} else {
+		err = yaml.Unmarshal(b, &f)
+	}
+	if err != nil { return nil, fmt.Errorf("%s: %w", path, err) }
+
+	if len(f.Tenants) == 0 { return nil, fmt.Errorf("%s: nenhum tenant", path) }
+	tenants := map[int]bool{}
+	for _, t := range f.Tenants { tenants[t.ID] = true }
+	codes := map[string]bool{}
+	for i := range f.Ads {
+		a := &f.Ads[i]
+		if !tenants[a.Tenant] { return nil, fmt.Errorf("%s: ads[%d] (%s): tenant %d não existe", path, i, a.Code, a.Tenant) }
+		if a.Code = strings.TrimSpace(a.Code); a.Code == "" { return nil, fmt.Errorf("%s: ads[%d] sem code", path, i) }
+		key := fmt.Sprintf("%d|%s", a.Tenant, strings.ToLower(a.Code))
+		if codes[key] && !a.Deleted { return nil, fmt.Errorf("%s: ads[%d]: code %q repetido no tenant %d", path, i, a.Code, a.Tenant) }
+		codes[key] = codes[key] || !a.Deleted
+		if a.UUID == "" { a.UUID = fmt.Sprintf("dev-%d-%s", a.Tenant, strings.ToLower(a.Code)) }
+	}
+	return &f, nil
+}
+
+// Registry converte os tenants para o registro (tenant.NewStaticSource).
+func (f *Fixture) Registry() []tenant.Tenant {
+	out := make([]tenant.Tenant, 0, len(f.Tenants))
+	for _, t := range f.Tenants {
+		out = append(out, tenant.Tenant{ID: t.ID, Portal: t.Portal, Aliases: t.Aliases, AdsURL: t.AdsURL, Static: t.Static, Timezone: t.Timezone, Origins: t.Origins})
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status de uma dependência / do serviço.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // funciona, mas sem algo opcional (ex.: Redis)
+	StatusFail     = "fail"
+)
+
+// Degraded marca o erro de uma checagem crítica como não fatal: a dependência caiu,
+// mas o serviço segue atendendo por um fallback (ex.: MySQL fora, servindo do snapshot).
+func Degraded(err error) error { return degradedError{err} }
+
+type degradedError struct{ error }
+
+func (e degradedError) Unwrap() error { return e.error }
+
+// CheckFunc testa uma dependência; detail vai no JSON mesmo quando err == nil.
+type CheckFunc func(ctx context.Context) (detail string, err error)
+
+type check struct {
+	name     string
+	critical bool // falha derruba a prontidão (503); não crítica só marca degraded
+	fn       CheckFunc
+}
+
+// Result é o resultado de uma checagem no /_health/ready.
+type Result struct {
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// Checker responde liveness e readiness.
+type Checker struct {
+	version string
+	started time.Time
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []check
+}
+
+// New cria o checker; timeout limita cada checagem do readiness.
+func New(version string, timeout time.Duration) *Checker {
+	return &Checker{version: version, started: time.Now(), timeout: timeout}
+}
+
+// Add registra uma checagem. critical=false: falha vira "degraded" (HTTP 200).
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.mu.Lock()
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+	c.mu.Unlock()
+}
+
+// Live: o processo está de pé e atendendo (não olha dependências).
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":  StatusOK,
+		"version": c.version,
+		"uptime":  time.Since(c.started).Round(time.Second).String(),
+	})
+}
+
+// Ready roda as checagens em paralelo: 200 (ok/degraded) ou 503 (alguma crítica falhou).
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make(map[string]Result, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ck := range checks {
+		wg.Add(1)
+		go func(ck check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+			defer cancel()
+			start := time.Now()
+			detail, err := ck.fn(ctx)
+			res := Result{Status: StatusOK, Detail: detail, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				res.Status, res.Error = StatusDegraded, err.Error()
+				var de degradedError
+				if ck.critical && !errors.As(err, &de) { res.Status = StatusFail }
+			}
+			mu.Lock()
+			results[ck.name] = res
+			mu.Unlock()
+		}(ck)
+	}
+	wg.Wait()
+
+	status, code := StatusOK, http.StatusOK
+	for _, res := range results {
+		switch res.Status {
+		case StatusFail:
+			status, code = StatusFail, http.StatusServiceUnavailable
+		case StatusDegraded:
+			if status == StatusOK { status = StatusDegraded }
+		}
+	}
+	writeJSON(w, code, map[string]any{"status": status, "version": c.version, "checks": results})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Version resolve a versão do build: -ldflags "-X main.version=v1.2.3" > arquivo VERSION
+// ao lado do binário (gravado pelo deploy.sh) > vcs.revision do go build > "dev".
+func Version(linked string) string {
+	if linked != "" { return linked }
+	if exe, err := os.Executable(); err == nil {
+		if real, err := filepath.EvalSymlinks(exe); err == nil { exe = real }
+		if b, err := os.ReadFile(filepath.Join(filepath.Dir(exe), "VERSION")); err == nil {
+			if v := strings.TrimSpace(string(b)); v != "" { return v }
+		}
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 { return s.Value[:12] }
+		}
+	}
+	return "dev"
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"ads-go/internal/telemetry"
+)
+
+// requisição mais lenta que isso sempre entra no access log
+const slowRequest = time.Second
+
+// AccessLog escreve uma linha "http_request" por requisição: erros (5xx) e lentas sempre,
+// o resto amostrado por sample() (0..1, recarregável). Precisa rodar depois do RequestID.
+func AccessLog(sample func() float64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			r = r.WithContext(telemetry.WithStart(r.Context(), start))
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status, lat := ww.Status(), time.Since(start)
+			if status == 0 { status = http.StatusOK }
+			if status < 500 && lat < slowRequest && rand.Float64() >= sample() { return }
+
+			level := slog.LevelInfo
+			if status >= 500 { level = slog.LevelError }
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil { ip = r.RemoteAddr }
+			// o tenant vem do header que o middleware Tenant põe na resposta (o contexto dele é interno)
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Int64("latency_ms", lat.Milliseconds()),
+				slog.String("ip", ip),
+				slog.String("user_agent", r.UserAgent()),
+			}
+			if id, err := strconv.Atoi(ww.Header().Get("X-Tenant-ID")); err == nil {
+				attrs = append(attrs, slog.Int("tenant", id))
+			}
+			slog.LogAttrs(r.Context(), level, "http_request", attrs...)
+		})
+	}
+}
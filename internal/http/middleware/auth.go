@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RequireAPIKey exige a chave em "X-API-Key" ou "Authorization: Bearer" (formato do scrape do Prometheus).
+// key() é lida a cada requisição (config recarregável).
+func RequireAPIKey(key func() string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validAPIKey(r, key()) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func validAPIKey(r *http.Request, want string) bool {
+	got := r.Header.Get("X-API-Key")
+	if got == "" { got, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ") }
+	return got != "" && want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+const (
+	adminMaxBody = 1 << 20         // corpo máximo das requisições do /admin
+	adminSkew    = 5 * time.Minute // tolerância do X-Timestamp (relógio do cliente)
+)
+
+// AdminAuth autentica o /admin por API key (X-API-Key / Bearer) ou por requisição assinada:
+//
+//	X-Timestamp: <unix segundos>
+//	X-Signature: v1=<hex(HMAC-SHA256(HMAC_SECRET, "ads-admin-v1\n" + método + "\n" + path?query + "\n" + timestamp + "\n" + hex(sha256(corpo))))>
+//
+// Assinatura fora da janela de adminSkew ou já vista (replay) é recusada; a memória de
+// assinaturas vai no Redis quando houver (vale entre instâncias), senão fica local.
+// keys() é lida a cada requisição (config recarregável). Limita o corpo a adminMaxBody.
+func AdminAuth(keys func() (apiKey, hmacSecret string), rdb redis.UniversalClient) func(http.Handler) http.Handler {
+	seen := &replayGuard{rdb: rdb, local: map[string]time.Time{}}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, adminMaxBody)
+			apiKey, secret := keys()
+			if validAPIKey(r, apiKey) { next.ServeHTTP(w, r); return }
+
+			reason := "sem credenciais"
+			if sig := r.Header.Get("X-Signature"); sig != "" {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				if reason = checkSignature(r, secret, sig, body); reason == "" {
+					if seen.replay(r, sig) { reason = "assinatura repetida" } else { next.ServeHTTP(w, r); return }
+				}
+			}
+			slog.WarnContext(r.Context(), "admin: acesso negado", "reason", reason, "method", r.Method, "path", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": "unauthorized"})
+		})
+	}
+}
+
+// checkSignature devolve "" se a assinatura confere, senão o motivo (só para o log).
+func checkSignature(r *http.Request, secret, sig string, body []byte) string {
+	if secret == "" { return "HMAC_SECRET vazio" }
+	ts := r.Header.Get("X-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil { return "X-Timestamp inválido" }
+	if d := time.Since(time.Unix(sec, 0)); d > adminSkew || d < -adminSkew { return "X-Timestamp fora da janela" }
+	got, err := hex.DecodeString(strings.TrimPrefix(sig, "v1="))
+	if err != nil || !strings.HasPrefix(sig, "v1=") { return "X-Signature malformada" }
+	if !hmac.Equal(got, AdminSignature(secret, r.Method, r.URL.RequestURI(), ts, body)) { return "assinatura não confere" }
+	return ""
+}
+
+// AdminSignature calcula a assinatura v1 (usada também por clientes/scripts em Go).
+func AdminSignature(secret, method, requestURI, timestamp string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("ads-admin-v1\n" + strings.ToUpper(method) + "\n" + requestURI + "\n" + timestamp + "\n" + hex.EncodeToString(sum[:])))
+	return h.Sum(nil)
+}
+
+// replayGuard lembra as assinaturas aceitas pela janela inteira (2*adminSkew).
+type replayGuard struct {
+	rdb   redis.UniversalClient
+	mu    sync.Mutex
+	local map[string]time.Time
+}
+
+func (g *replayGuard) replay(r *http.Request, sig string) bool {
+	if g.rdb != nil {
+		ok, err := g.rdb.SetNX(r.Context(), "admin:sig:"+sig, "1", 2*adminSkew).Result()
+		if err == nil { return !ok }
+		slog.WarnContext(r.Context(), "admin: redis indisponível, anti-replay local", "err", err)
+	}
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for k, exp := range g.local {
+		if now.After(exp) { delete(g.local, k) }
+	}
+	if _, ok := g.local[sig]; ok { return true }
+	g.local[sig] = now.Add(2 * adminSkew)
+	return false
+}
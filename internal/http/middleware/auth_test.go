@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testAPIKey = "chave-de-teste-com-mais-de-32-caracteres"
+	testSecret = "segredo-de-teste-com-mais-de-32-caracteres"
+)
+
+// signed monta uma requisição assinada; body é o que vai no corpo, signedBody o que entra na assinatura.
+func signed(method, target, body, signedBody string, ts time.Time, secret string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	stamp := strconv.FormatInt(ts.Unix(), 10)
+	r.Header.Set("X-Timestamp", stamp)
+	r.Header.Set("X-Signature", "v1="+hex.EncodeToString(AdminSignature(secret, method, r.URL.RequestURI(), stamp, []byte(signedBody))))
+	return r
+}
+
+func TestAdminAuth(t *testing.T) {
+	now := time.Now()
+	body := `{"code":"promo"}`
+	tests := []struct {
+		name string
+		req  func() *http.Request
+		weak bool // credenciais fracas: o main passa chaves vazias
+		want int
+	}{
+		{name: "assinatura válida", req: func() *http.Request { return signed("POST", "/admin/tenants/1/ads?x=1", body, body, now, testSecret) }, want: 200},
+		{name: "corpo adulterado", req: func() *http.Request { return signed("POST", "/admin/tenants/1/ads", `{"code":"outro"}`, body, now, testSecret) }, want: 401},
+		{name: "path adulterado", req: func() *http.Request {
+			r := signed("POST", "/admin/tenants/1/ads", body, body, now, testSecret)
+			r.URL.Path = "/admin/tenants/2/ads"
+			return r
+		}, want: 401},
+		{name: "método adulterado", req: func() *http.Request {
+			r := signed("POST", "/admin/tenants/1/ads/promo", body, body, now, testSecret)
+			r.Method = http.MethodDelete
+			return r
+		}, want: 401},
+		{name: "segredo errado", req: func() *http.Request { return signed("POST", "/admin/tenants/1/ads", body, body, now, "outro-segredo") }, want: 401},
+		{name: "timestamp antigo fora da janela", req: func() *http.Request { return signed("POST", "/admin/tenants/1/ads", body, body, now.Add(-adminSkew-time.Minute), testSecret) }, want: 401},
+		{name: "timestamp futuro fora da janela", req: func() *http.Request { return signed("POST", "/admin/tenants/1/ads", body, body, now.Add(adminSkew+time.Minute), testSecret) }, want: 401},
+		{name: "timestamp dentro da janela", req: func() *http.Request { return signed("POST", "/admin/tenants/1/ads", body, body, now.Add(-adminSkew+time.Minute), testSecret) }, want: 200},
+		{name: "assinatura malformada", req: func() *http.Request {
+			r := signed("POST", "/admin/tenants/1/ads", body, body, now, testSecret)
+			r.Header.Set("X-Signature", strings.TrimPrefix(r.Header.Get("X-Signature"), "v1="))
+			return r
+		}, want: 401},
+		{name: "sem credenciais", req: func() *http.Request { return httptest.NewRequest("GET", "/admin/tenants/1/ads/promo", nil) }, want: 401},
+		{name: "X-API-Key", req: func() *http.Request {
+			r := httptest.NewRequest("GET", "/admin/tenants/1/ads/promo", nil)
+			r.Header.Set("X-API-Key", testAPIKey)
+			return r
+		}, want: 200},
+		{name: "Bearer", req: func() *http.Request {
+			r := httptest.NewRequest("GET", "/admin/tenants/1/ads/promo", nil)
+			r.Header.Set("Authorization", "Bearer "+testAPIKey)
+			return r
+		}, want: 200},
+		{name: "API key errada", req: func() *http.Request {
+			r := httptest.NewRequest("GET", "/admin/tenants/1/ads/promo", nil)
+			r.Header.Set("X-API-Key", testAPIKey+"x")
+			return r
+		}, want: 401},
+		{name: "credenciais fracas: chaves vazias", weak: true, req: func() *http.Request {
+			r := signed("POST", "/admin/tenants/1/ads", body, body, now, "")
+			r.Header.Set("X-API-Key", "")
+			return r
+		}, want: 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := [2]string{testAPIKey, testSecret}
+			if tt.weak { keys = [2]string{} }
+			var gotBody string
+			h := AdminAuth(func() (string, string) { return keys[0], keys[1] }, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+			}))
+			req := tt.req()
+			sent := ""
+			if req.Body != nil {
+				b, _ := io.ReadAll(req.Body)
+				sent = string(b)
+				req.Body = io.NopCloser(strings.NewReader(sent))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want { t.Fatalf("status = %d, quer %d", w.Code, tt.want) }
+			if tt.want == 200 && gotBody != sent { t.Errorf("handler leu %q, quer o corpo original %q", gotBody, sent) }
+		})
+	}
+}
+
+func TestAdminAuthReplay(t *testing.T) {
+	h := AdminAuth(func() (string, string) { return testAPIKey, testSecret }, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	body := `{"code":"promo"}`
+	first := signed("POST", "/admin/tenants/1/ads", body, body, time.Now(), testSecret)
+	replay := httptest.NewRequest("POST", "/admin/tenants/1/ads", strings.NewReader(body))
+	replay.Header = first.Header.Clone()
+
+	for i, r := range []*http.Request{first, replay} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if want := []int{200, 401}[i]; w.Code != want { t.Fatalf("requisição %d: status = %d, quer %d", i+1, w.Code, want) }
+	}
+}
+
+func TestReplayGuardExpira(t *testing.T) {
+	g := &replayGuard{local: map[string]time.Time{}}
+	r := httptest.NewRequest("POST", "/", nil)
+	if g.replay(r, "v1=aa") { t.Fatal("primeira vez marcada como replay") }
+	if !g.replay(r, "v1=aa") { t.Fatal("segunda vez não marcada como replay") }
+	if g.replay(r, "v1=bb") { t.Fatal("outra assinatura marcada como replay") }
+
+	// fora da janela a entrada é podada (o timestamp já seria recusado pelo checkSignature)
+	g.local["v1=aa"] = time.Now().Add(-time.Second)
+	if g.replay(r, "v1=aa") { t.Fatal("assinatura expirada ainda bloqueada") }
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	for _, tt := range []struct {
+		name, want, header, value string
+		code                      int
+	}{
+		{"X-API-Key", testAPIKey, "X-API-Key", testAPIKey, 200},
+		{"Bearer", testAPIKey, "Authorization", "Bearer " + testAPIKey, 200},
+		{"errada", testAPIKey, "X-API-Key", "changeme", 401},
+		{"sem header", testAPIKey, "", "", 401},
+		{"chave vazia não libera ninguém", "", "X-API-Key", "", 401},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RequireAPIKey(func() string { return tt.want })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.header != "" { r.Header.Set(tt.header, tt.value) }
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.code { t.Errorf("status = %d, quer %d", w.Code, tt.code) }
+		})
+	}
+}
@@ -1,28 +1,106 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"ads-go/internal/tenant"
 )
 
-func CORS(allowed []string) func(http.Handler) http.Handler {
-	allow := map[string]struct{}{}
-	for _, o := range allowed { allow[o] = struct{}{} }
+// CORSOptions configura o CORS por tenant (ver CORS).
+type CORSOptions struct {
+	Extra       []string // origens aceitas em qualquer tenant (ex.: http://localhost:3000 em dev)
+	Credentials bool     // envia Access-Control-Allow-Credentials: true
+	Methods     []string
+	Headers     []string
+	MaxAge      time.Duration // cache do preflight no navegador
+}
+
+func matchAny(patterns []string, origin string) bool {
+	for _, p := range patterns {
+		if tenant.MatchOrigin(p, origin) { return true }
+	}
+	return false
+}
+
+// CORS aplica a política do tenant da requisição (precisa rodar depois do middleware Tenant):
+// só as origens do próprio tenant (tenant.AllowedOrigins) + Extra.
+// Preflight (OPTIONS com Access-Control-Request-Method) é respondido aqui com 204.
+// options() é lido a cada requisição (config recarregável).
+func CORS(options func() CORSOptions) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if o := r.Header.Get("Origin"); o != "" {
-				if _, ok := allow[o]; ok { w.Header().Set("Access-Control-Allow-Origin", o); w.Header().Set("Vary","Origin"); w.Header().Set("Access-Control-Allow-Credentials","true"); w.Header().Set("Access-Control-Allow-Headers","Content-Type, Authorization") }
+			// CORS do AMP (__amp_source_origin + cache AMP como Origin) é tratado no handler AMP
+			if r.URL.Query().Has("__amp_source_origin") { next.ServeHTTP(w, r); return }
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin != "" {
+				h := w.Header()
+				h.Add("Vary", "Origin")
+				if preflight { h.Add("Vary", "Access-Control-Request-Method"); h.Add("Vary", "Access-Control-Request-Headers") }
+
+				opt := options()
+				t, _ := tenant.FromContext(r.Context())
+				if t.AllowsOrigin(origin) || matchAny(opt.Extra, origin) {
+					h.Set("Access-Control-Allow-Origin", origin)
+					if opt.Credentials { h.Set("Access-Control-Allow-Credentials", "true") }
+					if preflight {
+						h.Set("Access-Control-Allow-Methods", strings.Join(opt.Methods, ", "))
+						h.Set("Access-Control-Allow-Headers", strings.Join(opt.Headers, ", "))
+						h.Set("Access-Control-Max-Age", strconv.Itoa(int(opt.MaxAge.Seconds())))
+					}
+				} else {
+					slog.WarnContext(r.Context(), "cors: origem recusada", "origin", origin, "path", r.URL.Path)
+				}
 			}
-			if r.Method == http.MethodOptions { w.WriteHeader(204); return }
+			if r.Method == http.MethodOptions { w.WriteHeader(http.StatusNoContent); return }
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func OnlyGET() func(http.Handler) http.Handler {
+// métodos que o MethodNotAllowed testa para montar o Allow
+var routeMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+// MethodNotAllowed responde 405 com o Allow da rota: os métodos registrados nela
+// (HEAD junto com GET, que o chimw.GetHead atende). Use em chi.Mux.MethodNotAllowed.
+// Nunca pode ser o destino de um preflight de rota pública: elas registram OPTIONS (routes.Register),
+// para o middleware CORS do grupo rodar; por isso o Allow delas lista OPTIONS.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	var allow []string
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil {
+		for _, m := range routeMethods {
+			ok := rctx.Routes.Match(chi.NewRouteContext(), m, r.URL.Path)
+			if !ok && m == http.MethodHead { ok = rctx.Routes.Match(chi.NewRouteContext(), http.MethodGet, r.URL.Path) }
+			if ok { allow = append(allow, m) }
+		}
+	}
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+}
+
+// Tenant resolve o tenant uma vez por requisição e o guarda no contexto (tenant.FromContext).
+// Com strict() = true, host desconhecido recebe 421 Misdirected Request; senão cai no tenant padrão.
+// O ID vai no header X-Tenant-ID para facilitar o debug.
+func Tenant(strict func() bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodGet { http.Error(w, "Método não permitido", 405); return }
-			next.ServeHTTP(w, r)
+			t, err := tenant.Resolve(r.Host, r.Header.Get("X-Forwarded-Host"))
+			if err != nil {
+				if strict() {
+					slog.WarnContext(r.Context(), "tenant desconhecido", "host", r.Host, "x_forwarded_host", r.Header.Get("X-Forwarded-Host"), "ip", r.RemoteAddr, "err", err)
+					http.Error(w, "Host não atendido por este serviço", http.StatusMisdirectedRequest)
+					return
+				}
+				t = tenant.FromRequestHost(r.Host, r.Header.Get("X-Forwarded-Host"))
+			}
+			w.Header().Set("X-Tenant-ID", strconv.Itoa(t.ID))
+			next.ServeHTTP(w, r.WithContext(tenant.WithContext(r.Context(), t)))
 		})
 	}
 }
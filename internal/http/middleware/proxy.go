@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// headers de encaminhamento: só valem se o peer imediato for um proxy confiável
+var forwardHeaders = []string{"X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Proto", "X-Real-IP", "CF-Connecting-IP", "Forwarded"}
+
+// TrustedProxies é a lista de redes (CIDR) cujos headers de encaminhamento aceitamos.
+type TrustedProxies struct {
+	nets []*net.IPNet
+	cdn  []*net.IPNet // faixas dos arquivos (Cloudflare): só um hop delas pode mandar o CF-Connecting-IP
+}
+
+// NewTrustedProxies monta a lista a partir de CIDRs (ou IPs soltos) e, opcionalmente,
+// de arquivos com um CIDR por linha (ex.: faixas da Cloudflare baixadas localmente; # comenta).
+func NewTrustedProxies(cidrs []string, files ...string) (*TrustedProxies, error) {
+	tp := &TrustedProxies{}
+	for _, c := range cidrs {
+		if _, err := tp.add(c); err != nil { return nil, err }
+	}
+	for _, f := range files {
+		if f == "" { continue }
+		if err := tp.addFile(f); err != nil { return nil, err }
+	}
+	return tp, nil
+}
+
+func (tp *TrustedProxies) add(c string) (*net.IPNet, error) {
+	c = strings.TrimSpace(c)
+	if c == "" { return nil, nil }
+	if !strings.Contains(c, "/") {
+		ip := net.ParseIP(c)
+		if ip == nil { return nil, fmt.Errorf("proxy confiável inválido: %q", c) }
+		if ip.To4() != nil { c += "/32" } else { c += "/128" }
+	}
+	_, n, err := net.ParseCIDR(c)
+	if err != nil { return nil, fmt.Errorf("proxy confiável inválido: %q: %w", c, err) }
+	tp.nets = append(tp.nets, n)
+	return n, nil
+}
+
+func (tp *TrustedProxies) addFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil { return err }
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 { line = line[:i] }
+		n, err := tp.add(line)
+		if err != nil { return fmt.Errorf("%s: %w", path, err) }
+		if n != nil { tp.cdn = append(tp.cdn, n) }
+	}
+	return sc.Err()
+}
+
+// Trusted diz se o IP pertence a algum proxy confiável.
+func (tp *TrustedProxies) Trusted(ip net.IP) bool {
+	if tp == nil { return false }
+	return contains(tp.nets, ip)
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil { return false }
+	for _, n := range nets {
+		if n.Contains(ip) { return true }
+	}
+	return false
+}
+
+func peerIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil { host = remoteAddr }
+	return net.ParseIP(strings.TrimSpace(host))
+}
+
+// clientIP descobre o IP do cliente para um peer confiável: X-Forwarded-For lido da direita
+// para a esquerda, pulando os proxies confiáveis (o primeiro não confiável é o cliente).
+// CF-Connecting-IP só vale quando o hop que entregou a requisição ao nosso proxy está nas
+// faixas da Cloudflare (TRUSTED_PROXIES_FILE): vindo direto ao nginx, é do cliente e é ignorado.
+func (tp *TrustedProxies) clientIP(r *http.Request, peer net.IP) net.IP {
+	cf := net.ParseIP(strings.TrimSpace(r.Header.Get("CF-Connecting-IP")))
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	client := peer
+	for i := len(hops) - 1; ; i-- {
+		if cf != nil && contains(tp.cdn, client) { return cf }
+		if i < 0 || !tp.Trusted(client) { return client }
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil { return client } // lixo na cadeia: para no último IP válido
+		client = ip
+	}
+}
+
+// RealIP substitui o middleware.RealIP do chi: só confia nos headers de encaminhamento
+// quando o peer imediato é um proxy confiável. Nesse caso RemoteAddr passa a ser o IP do cliente;
+// caso contrário os headers são removidos, para que nada adiante (tenant, log de cliques) os use.
+func RealIP(tp *TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer := peerIP(r.RemoteAddr)
+			if tp.Trusted(peer) {
+				if ip := tp.clientIP(r, peer); ip != nil {
+					r.RemoteAddr = ip.String()
+				}
+			} else {
+				for _, h := range forwardHeaders { r.Header.Del(h) }
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
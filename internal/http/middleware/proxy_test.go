@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	cdn := filepath.Join(t.TempDir(), "cloudflare.txt")
+	if err := os.WriteFile(cdn, []byte("# faixas da CDN\n173.245.48.0/20\n"), 0o644); err != nil { t.Fatal(err) }
+	tp, err := NewTrustedProxies([]string{"10.0.0.0/8", "::1"}, cdn)
+	if err != nil { t.Fatal(err) }
+
+	tests := []struct {
+		name     string
+		peer     string
+		xff      []string
+		cf       string
+		fwdHost  string
+		want     string
+		stripped bool // headers de encaminhamento removidos
+	}{
+		{name: "peer não confiável: headers descartados", peer: "203.0.113.5:1234", xff: []string{"1.1.1.1"}, cf: "9.9.9.9", fwdHost: "evil.example", want: "203.0.113.5:1234", stripped: true},
+		{name: "X-Forwarded-Host de peer não confiável", peer: "203.0.113.5:1234", fwdHost: "evil.example", want: "203.0.113.5:1234", stripped: true},
+		{name: "proxy confiável sem XFF", peer: "10.0.0.1:80", want: "10.0.0.1"},
+		{name: "um hop", peer: "10.0.0.1:80", xff: []string{"1.1.1.1"}, fwdHost: "portal.example", want: "1.1.1.1"},
+		{name: "cadeia: pula os proxies confiáveis", peer: "10.0.0.1:80", xff: []string{"1.1.1.1, 10.0.0.2, 10.0.0.3"}, want: "1.1.1.1"},
+		{name: "cadeia: IP forjado à esquerda ignorado", peer: "10.0.0.1:80", xff: []string{"6.6.6.6, 1.1.1.1"}, want: "1.1.1.1"},
+		{name: "cadeia em vários headers", peer: "10.0.0.1:80", xff: []string{"1.1.1.1", "10.0.0.2"}, want: "1.1.1.1"},
+		{name: "hop malformado: para no último válido", peer: "10.0.0.1:80", xff: []string{"1.1.1.1, lixo"}, want: "10.0.0.1"},
+		{name: "hop malformado depois de confiável", peer: "10.0.0.1:80", xff: []string{"lixo, 10.0.0.2"}, want: "10.0.0.2"},
+		{name: "CF-Connecting-IP de hop fora da CDN", peer: "10.0.0.1:80", xff: []string{"5.5.5.5"}, cf: "9.9.9.9", want: "5.5.5.5"},
+		{name: "CF-Connecting-IP direto ao proxy", peer: "10.0.0.1:80", cf: "9.9.9.9", want: "10.0.0.1"},
+		{name: "CF-Connecting-IP via CDN", peer: "10.0.0.1:80", xff: []string{"1.1.1.1, 173.245.48.10"}, cf: "9.9.9.9", want: "9.9.9.9"},
+		{name: "IPv6", peer: "[::1]:80", xff: []string{"2001:db8::1"}, want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			h := RealIP(tp)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r }))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.peer
+			for _, v := range tt.xff { r.Header.Add("X-Forwarded-For", v) }
+			if tt.cf != "" { r.Header.Set("CF-Connecting-IP", tt.cf) }
+			if tt.fwdHost != "" { r.Header.Set("X-Forwarded-Host", tt.fwdHost) }
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got.RemoteAddr != tt.want { t.Errorf("RemoteAddr = %q, quer %q", got.RemoteAddr, tt.want) }
+			for _, name := range forwardHeaders {
+				if v := got.Header.Get(name); tt.stripped && v != "" { t.Errorf("%s = %q, quer removido", name, v) }
+			}
+			if !tt.stripped && tt.fwdHost != "" && got.Header.Get("X-Forwarded-Host") != tt.fwdHost { t.Errorf("X-Forwarded-Host de proxy confiável removido") }
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"ads-go/internal/metrics"
+	"ads-go/internal/ratelimit"
+	"ads-go/internal/tenant"
+)
+
+// Escopos de orçamento de um grupo de rotas.
+const (
+	ScopeIP     = "ip"     // por IP do cliente (IPv6 agrupado por /64)
+	ScopeTenant = "tenant" // soma de todos os clientes do tenant
+)
+
+// RateLimit aplica os orçamentos do grupo (ex.: "short"): primeiro o do IP, depois o do tenant;
+// estourado, responde 429 com Retry-After. limit(group, scope) é lida a cada requisição
+// (config recarregável); sem limite configurado, o escopo não é checado.
+// Precisa rodar depois do RealIP e do Tenant.
+func RateLimit(lim *ratelimit.Limiter, group string, limit func(group, scope string) (ratelimit.Limit, bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys := [...]struct{ scope, id string }{{ScopeIP, clientKey(r.RemoteAddr)}, {ScopeTenant, ""}}
+			if t, ok := tenant.FromContext(r.Context()); ok { keys[1].id = strconv.Itoa(t.ID) }
+			for _, k := range keys {
+				l, ok := limit(group, k.scope)
+				if !ok || k.id == "" { continue }
+				allowed, retry := lim.Allow(r.Context(), "rl:"+group+":"+k.scope+":"+k.id, l)
+				if allowed { continue }
+				metrics.RateLimited.WithLabelValues(group, k.scope).Inc()
+				w.Header().Set("Retry-After", ratelimit.RetryAfter(retry))
+				w.Header().Set("Cache-Control", "no-store")
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey: IPv4 inteiro; IPv6 pelo /64 (um cliente costuma ter o prefixo todo).
+func clientKey(remoteAddr string) string {
+	ip := peerIP(remoteAddr)
+	if ip == nil { return "" }
+	if v4 := ip.To4(); v4 != nil { return v4.String() }
+	return ip.Mask(net.CIDRMask(64, 128)).String() + "/64"
+}
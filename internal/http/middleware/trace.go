@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"ads-go/internal/telemetry"
+)
+
+// Trace abre o span de servidor de cada requisição, continuando o traceparent recebido.
+// A rota (padrão do chi) é resolvida antes do span para o sampler por rota e para o nome
+// ("GET /{short}") — nunca o path cru, que tem o código do shortlink. Método fora da lista
+// conhecida vira "other" (methodLabel), como nas métricas.
+func Trace(routes chi.Routes) func(http.Handler) http.Handler {
+	tracer := telemetry.Tracer("http")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			route := "unmatched"
+			if rctx := chi.NewRouteContext(); routes.Match(rctx, r.Method, r.URL.Path) { route = rctx.RoutePattern() }
+
+			method := methodLabel(r.Method)
+			ctx, span := tracer.Start(ctx, method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRoute(route),
+					semconv.HTTPRequestMethodKey.String(method),
+					semconv.UserAgentOriginal(r.UserAgent()),
+				),
+			)
+			defer span.End()
+			if id := chimw.GetReqID(ctx); id != "" { span.SetAttributes(attribute.String("request_id", id)) }
+
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 { status = http.StatusOK }
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if t := ww.Header().Get("X-Tenant-ID"); t != "" { span.SetAttributes(attribute.String("tenant.id", t)) }
+			if status >= 500 { span.SetStatus(codes.Error, strconv.Itoa(status)) }
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"ads-go/internal/metrics"
+	"ads-go/internal/safeurl"
+	mysqldb "ads-go/internal/storage/mysql"
+	"ads-go/internal/tenant"
+)
+
+type adminDeps struct {
+	DB    *mysqldb.DB
+	Rdb   redis.UniversalClient
+	Guard *safeurl.Checker
+	Snap  *LastGood
+}
+
+// adminAd é uma linha de ads como o /admin lê e grava.
+type adminAd struct {
+	Code        string          `json:"code"`
+	UUID        string          `json:"uuid"`
+	Description string          `json:"description"`
+	Redirect    string          `json:"redirect"`
+	Status      int             `json:"status"` // 1 = ativo
+	Breakpoint  int             `json:"breackpoint"`
+	Types       json.RawMessage `json:"types,omitempty"` // {"3":{"file":"...","extension":"png"}}
+	Variants    []shortVariant  `json:"variants,omitempty"`
+	StartedAt   *time.Time      `json:"started_at"`
+	ValidateAt  *time.Time      `json:"validate_at"`
+}
+
+var (
+	adminCodeRe    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	errAdNotFound  = errors.New("anúncio não encontrado")
+	errAdDuplicate = errors.New("código já existe no tenant")
+)
+
+func adminJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func adminError(w http.ResponseWriter, code int, msg string) {
+	adminJSON(w, code, map[string]any{"error": msg})
+}
+
+// params lê {tenant} (precisa existir no registro) e {code}.
+func (d adminDeps) params(w http.ResponseWriter, r *http.Request) (tenantID int, code string, ok bool) {
+	tenantID, err := strconv.Atoi(chi.URLParam(r, "tenant"))
+	if err != nil || !slices.Contains(tenant.IDs(), tenantID) {
+		adminError(w, http.StatusNotFound, "tenant desconhecido")
+		return 0, "", false
+	}
+	if d.DB == nil {
+		adminError(w, http.StatusServiceUnavailable, "sem MySQL (modo dev)")
+		return 0, "", false
+	}
+	if !d.DB.Up() {
+		adminError(w, http.StatusServiceUnavailable, "mysql fora do ar")
+		return 0, "", false
+	}
+	return tenantID, chi.URLParam(r, "code"), true
+}
+
+// validate confere o anúncio antes de gravar; destinos passam pelo safeurl (blocklist/allowlist do tenant).
+func (d adminDeps) validate(tenantID int, a adminAd) error {
+	if !adminCodeRe.MatchString(a.Code) { return errors.New("code: use 1-64 caracteres [A-Za-z0-9_-]") }
+	if err := d.Guard.Check(tenantID, a.Redirect); err != nil { return fmt.Errorf("redirect: %w", err) }
+	if a.Status != 0 && a.Status != 1 { return errors.New("status: 0 ou 1") }
+	if a.StartedAt != nil && a.ValidateAt != nil && !a.ValidateAt.After(*a.StartedAt) {
+		return errors.New("validate_at precisa ser depois de started_at")
+	}
+	if len(a.Types) > 0 {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(a.Types, &obj); err != nil { return errors.New("types: precisa ser um objeto JSON") }
+	}
+	keys := map[string]bool{}
+	for i, v := range a.Variants {
+		if v.Key == "" || keys[v.Key] || v.Weight <= 0 { return fmt.Errorf("variants[%d]: key única e weight > 0", i) }
+		if err := d.Guard.Check(tenantID, v.URL); err != nil { return fmt.Errorf("variants[%d].url: %w", i, err) }
+		keys[v.Key] = true
+	}
+	return nil
+}
+
+// decode lê o corpo JSON sobre dst (campos ausentes ficam como estão: serve ao PATCH).
+func decode(r *http.Request, dst *adminAd) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil { return fmt.Errorf("json inválido: %w", err) }
+	return nil
+}
+
+// GET /admin/tenants/{tenant}/ads/{code}
+func (d adminDeps) GetAd(w http.ResponseWriter, r *http.Request) {
+	tenantID, code, ok := d.params(w, r)
+	if !ok { return }
+	a, err := d.load(r.Context(), tenantID, code)
+	if !d.dbResult(w, r, "admin_get", err) { return }
+	adminJSON(w, http.StatusOK, a)
+}
+
+// POST /admin/tenants/{tenant}/ads → 201 com o anúncio (uuid gerado aqui)
+func (d adminDeps) CreateAd(w http.ResponseWriter, r *http.Request) {
+	tenantID, _, ok := d.params(w, r)
+	if !ok { return }
+	a := adminAd{Status: 1}
+	if err := decode(r, &a); err != nil { adminError(w, http.StatusBadRequest, err.Error()); return }
+	a.UUID = uuid.NewString()
+	if err := d.validate(tenantID, a); err != nil { adminError(w, http.StatusUnprocessableEntity, err.Error()); return }
+	if !d.dbResult(w, r, "admin_insert", d.insert(r.Context(), tenantID, a)) { return }
+	d.invalidate(r.Context(), tenantID, a.Code)
+	slog.InfoContext(r.Context(), "admin: anúncio criado", "tenant", tenantID, "code", a.Code)
+	w.Header().Set("Location", fmt.Sprintf("/admin/tenants/%d/ads/%s", tenantID, a.Code))
+	adminJSON(w, http.StatusCreated, a)
+}
+
+// PATCH /admin/tenants/{tenant}/ads/{code} — só os campos enviados mudam (code e uuid não mudam)
+func (d adminDeps) UpdateAd(w http.ResponseWriter, r *http.Request) {
+	tenantID, code, ok := d.params(w, r)
+	if !ok { return }
+	cur, err := d.load(r.Context(), tenantID, code)
+	if !d.dbResult(w, r, "admin_get", err) { return }
+	a := cur
+	if err := decode(r, &a); err != nil { adminError(w, http.StatusBadRequest, err.Error()); return }
+	a.Code, a.UUID = cur.Code, cur.UUID
+	if err := d.validate(tenantID, a); err != nil { adminError(w, http.StatusUnprocessableEntity, err.Error()); return }
+	if !d.dbResult(w, r, "admin_update", d.update(r.Context(), tenantID, a)) { return }
+	d.invalidate(r.Context(), tenantID, a.Code)
+	slog.InfoContext(r.Context(), "admin: anúncio alterado", "tenant", tenantID, "code", a.Code)
+	adminJSON(w, http.StatusOK, a)
+}
+
+// DELETE /admin/tenants/{tenant}/ads/{code} — soft delete (deleted_at), como o resto das consultas espera
+func (d adminDeps) DeleteAd(w http.ResponseWriter, r *http.Request) {
+	tenantID, code, ok := d.params(w, r)
+	if !ok { return }
+	if !d.dbResult(w, r, "admin_delete", d.softDelete(r.Context(), tenantID, code)) { return }
+	d.invalidate(r.Context(), tenantID, code)
+	slog.InfoContext(r.Context(), "admin: anúncio removido", "tenant", tenantID, "code", code)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dbResult traduz o erro do MySQL em resposta; true = seguir.
+func (d adminDeps) dbResult(w http.ResponseWriter, r *http.Request, op string, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errAdNotFound):
+		adminError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errAdDuplicate):
+		adminError(w, http.StatusConflict, err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "admin: erro no mysql", "op", op, "err", err)
+		metrics.MySQLErrors.WithLabelValues(op).Inc()
+		adminError(w, http.StatusInternalServerError, "erro no banco")
+	}
+	return false
+}
+
+// invalidate tira o shortlink dos caches (Redis positivo/negativo e conjunto quente do snapshot).
+func (d adminDeps) invalidate(ctx context.Context, tenantID int, code string) {
+	d.Snap.dropShort(tenantID, code)
+	if d.Rdb == nil { return }
+	key := shortDeps{}.getKey(tenant.Tenant{ID: tenantID}, code)
+	if err := d.Rdb.Del(ctx, key, shortDeps{}.nfKey(key)).Err(); err != nil {
+		slog.WarnContext(ctx, "admin: falha ao limpar cache do shortlink", "code", code, "err", err)
+	}
+}
+
+// load lê do primário: logo depois de uma escrita a réplica pode estar atrasada.
+func (d adminDeps) load(ctx context.Context, tenantID int, code string) (adminAd, error) {
+	ctx, cancel := d.DB.WriteContext(ctx)
+	defer cancel()
+	const q = `
+		SELECT code, uuid, COALESCE(description,''), COALESCE(redirect,''), status, breackpoint,
+		       types, variants, started_at, validate_at
+		FROM ads
+		WHERE tenant_id = ? AND code = ? AND deleted_at IS NULL
+		LIMIT 1
+	`
+	var (
+		a                      adminAd
+		typesJSON, variantsJSON sql.NullString
+		startedAt, validateAt   sql.NullTime
+	)
+	err := d.DB.Primary().QueryRowContext(ctx, q, tenantID, code).Scan(&a.Code, &a.UUID, &a.Description, &a.Redirect,
+		&a.Status, &a.Breakpoint, &typesJSON, &variantsJSON, &startedAt, &validateAt)
+	if errors.Is(err, sql.ErrNoRows) { return adminAd{}, errAdNotFound }
+	if err != nil { return adminAd{}, err }
+	if typesJSON.Valid && json.Valid([]byte(typesJSON.String)) { a.Types = json.RawMessage(typesJSON.String) }
+	if variantsJSON.Valid { a.Variants = parseVariants(variantsJSON.String) }
+	if startedAt.Valid { a.StartedAt = &startedAt.Time }
+	if validateAt.Valid { a.ValidateAt = &validateAt.Time }
+	return a, nil
+}
+
+// columns devolve os valores das colunas JSON/opcionais (NULL quando vazias).
+func (a adminAd) columns() (types, variants sql.NullString, startedAt, validateAt sql.NullTime) {
+	if len(a.Types) > 0 { types = sql.NullString{String: string(a.Types), Valid: true} }
+	if len(a.Variants) > 0 {
+		b, _ := json.Marshal(a.Variants)
+		variants = sql.NullString{String: string(b), Valid: true}
+	}
+	if a.StartedAt != nil { startedAt = sql.NullTime{Time: *a.StartedAt, Valid: true} }
+	if a.ValidateAt != nil { validateAt = sql.NullTime{Time: *a.ValidateAt, Valid: true} }
+	return
+}
+
+func (d adminDeps) insert(ctx context.Context, tenantID int, a adminAd) error {
+	ctx, cancel := d.DB.WriteContext(ctx)
+	defer cancel()
+	// código de um anúncio removido (deleted_at) pode ser reaproveitado
+	var n int
+	err := d.DB.Primary().QueryRowContext(ctx, `SELECT COUNT(*) FROM ads WHERE tenant_id = ? AND code = ? AND deleted_at IS NULL`, tenantID, a.Code).Scan(&n)
+	if err != nil { return err }
+	if n > 0 { return errAdDuplicate }
+	types, variants, startedAt, validateAt := a.columns()
+	const q = `
+		INSERT INTO ads (tenant_id, code, uuid, description, redirect, status, breackpoint, types, variants, started_at, validate_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = d.DB.ExecContext(ctx, q, tenantID, a.Code, a.UUID, nullIfEmpty(a.Description), a.Redirect, a.Status, a.Breakpoint, types, variants, startedAt, validateAt)
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == 1062 { return errAdDuplicate } // índice único, se houver
+	return err
+}
+
+func (d adminDeps) update(ctx context.Context, tenantID int, a adminAd) error {
+	ctx, cancel := d.DB.WriteContext(ctx)
+	defer cancel()
+	types, variants, startedAt, validateAt := a.columns()
+	const q = `
+		UPDATE ads
+		SET description = ?, redirect = ?, status = ?, breackpoint = ?, types = ?, variants = ?, started_at = ?, validate_at = ?
+		WHERE tenant_id = ? AND code = ? AND deleted_at IS NULL
+	`
+	res, err := d.DB.ExecContext(ctx, q, nullIfEmpty(a.Description), a.Redirect, a.Status, a.Breakpoint, types, variants, startedAt, validateAt, tenantID, a.Code)
+	if err != nil { return err }
+	// RowsAffected = 0 também quando nada mudou; só é "não encontrado" se a linha sumiu nesse meio-tempo
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := d.load(ctx, tenantID, a.Code); err != nil { return err }
+	}
+	return nil
+}
+
+func (d adminDeps) softDelete(ctx context.Context, tenantID int, code string) error {
+	ctx, cancel := d.DB.WriteContext(ctx)
+	defer cancel()
+	res, err := d.DB.ExecContext(ctx, `UPDATE ads SET deleted_at = NOW() WHERE tenant_id = ? AND code = ? AND deleted_at IS NULL`, tenantID, code)
+	if err != nil { return err }
+	if n, _ := res.RowsAffected(); n == 0 { return errAdNotFound }
+	return nil
+}
@@ -2,12 +2,11 @@ package routes
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"ads-go/internal/ads"
 	"ads-go/internal/config"
-	"ads-go/internal/tenant"
 )
 
 type adsDeps struct {
@@ -25,12 +24,12 @@ type nodeResponse struct {
 // Handler compatível com o Node na raiz "/"
 func (d adsDeps) AdsRoot(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	t := tenant.FromRequestHost(r.Host, r.Header.Get("X-Forwarded-Host"))
+	t := reqTenant(r)
 
 	// Se quiser, use o query param "type=" para alterar lógica; por enquanto entregamos todos ativos
 	items, err := d.Repo.ActiveItems(r.Context(), t.ID, []int{1,2,3,4})
 	if err != nil {
-		log.Printf("[ads root] mysql err: %v", err)
+		slog.ErrorContext(r.Context(), "ads root: erro no catálogo", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error":"internal"})
 		return
@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ads-go/internal/metrics"
+	"ads-go/internal/tenant"
+)
+
+// Caches AMP que podem servir as páginas dos portais (Origin das requisições vindas do cache).
+var ampCacheSuffixes = []string{"cdn.ampproject.org", "bing-amp.com"}
+
+// ampCacheHost converte o host do publisher no subdomínio do cache AMP:
+// "www.gazeta-x.com.br" -> "www-gazeta--x-com-br".
+func ampCacheHost(host string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(host, "-", "--"), ".", "-")
+}
+
+// ampOriginOK valida o par (Origin, __amp_source_origin) segundo o protocolo CORS do AMP:
+// a origem de origem precisa ser do tenant; o Origin pode ser ela mesma, o cache AMP dela,
+// ou ausente com "AMP-Same-Origin: true".
+func ampOriginOK(r *http.Request, t tenant.Tenant, source string) bool {
+	if !t.AllowsOrigin(source) { return false }
+	origin := strings.ToLower(r.Header.Get("Origin"))
+	if origin == "" { return r.Header.Get("AMP-Same-Origin") == "true" }
+	if origin == strings.ToLower(source) { return true }
+	su, err := url.Parse(source)
+	if err != nil || su.Host == "" { return false }
+	host := tenant.NormalizeHost(su.Host)
+	for _, suf := range ampCacheSuffixes {
+		if origin == "https://"+ampCacheHost(host)+"."+suf { return true }
+	}
+	return false
+}
+
+// formato que os templates do amp-list esperam: {"items":[...]}
+type ampItem struct {
+	Code        string            `json:"code"`
+	Description string            `json:"description,omitempty"`
+	Breackpoint int               `json:"breackpoint"`
+	Image       string            `json:"image,omitempty"` // do tipo pedido em ?type=
+	Images      map[string]string `json:"images"`          // tipo -> URL absoluta no Static
+	ClickURL    string            `json:"click_url"`
+}
+
+type ampResp struct {
+	Items []ampItem `json:"items"`
+}
+
+// GET /amp/ads?__amp_source_origin=...&type=3&max=2
+// Mesma fonte do "/", já embaralhado (template AMP não sorteia) e com URLs absolutas.
+func (d adsNodeDeps) AMP(w http.ResponseWriter, r *http.Request) {
+	t := reqTenant(r)
+	q := r.URL.Query()
+	source := q.Get("__amp_source_origin")
+	if source == "" || !ampOriginOK(r, t, source) {
+		slog.WarnContext(r.Context(), "ads amp: origem recusada", "origin", r.Header.Get("Origin"), "source_origin", source)
+		http.Error(w, "origem não permitida", http.StatusForbidden)
+		return
+	}
+	h := w.Header()
+	if o := r.Header.Get("Origin"); o != "" {
+		h.Set("Access-Control-Allow-Origin", o)
+		h.Set("Access-Control-Allow-Credentials", "true")
+		h.Add("Vary", "Origin")
+	}
+	h.Set("AMP-Access-Control-Allow-Source-Origin", source)
+	h.Set("Access-Control-Expose-Headers", "AMP-Access-Control-Allow-Source-Origin")
+	h.Set("Content-Type", "application/json")
+	h.Set("Cache-Control", "no-store")
+
+	items, stale, err := d.catalog(r.Context(), t.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "ads amp: erro no catálogo", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "internal"})
+		return
+	}
+
+	if stale { h.Set("X-Catalog-Stale", "1") }
+
+	want, _ := strconv.Atoi(q.Get("type"))
+	static := strings.TrimRight(t.Static, "/")
+	portal := portalURL(r, t, d.Dev)
+	out := make([]ampItem, 0, len(items))
+	for _, it := range items {
+		ai := ampItem{
+			Code: it.Code, Description: it.Description, Breackpoint: it.Breackpoint,
+			Images:   map[string]string{},
+			ClickURL: canonicalShortURL(portal, it.Code) + "?src=amp",
+		}
+		for tp, v := range it.Types {
+			if v.File == "" { continue }
+			file := v.File
+			if v.Extension != "" { file += "." + v.Extension }
+			ai.Images[strconv.Itoa(tp)] = static + "/" + file
+			if tp == want { ai.Image = static + "/" + file }
+		}
+		if want > 0 && ai.Image == "" { continue } // pediu um tipo que o anúncio não tem
+		out = append(out, ai)
+	}
+	rand.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	if max, err := strconv.Atoi(q.Get("max")); err == nil && max > 0 && max < len(out) {
+		out = out[:max]
+	}
+	metrics.AdsServed.WithLabelValues(metrics.Tenant(t.ID), "amp").Add(float64(len(out)))
+	_ = json.NewEncoder(w).Encode(ampResp{Items: out})
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"ads-go/internal/tenant"
+)
+
+func TestAMPCacheHost(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"portal.example", "portal-example"},
+		{"www.gazeta-x.com.br", "www-gazeta--x-com-br"},
+		{"a--b.example", "a----b-example"},
+		{"xn--caf-dma.example", "xn----caf--dma-example"},
+	}
+	for _, tt := range tests {
+		if got := ampCacheHost(tt.in); got != tt.want { t.Errorf("ampCacheHost(%q) = %q, quer %q", tt.in, got, tt.want) }
+	}
+}
+
+func TestAMPOriginOK(t *testing.T) {
+	tn := tenant.Tenant{ID: 1, Portal: "gazeta-x.com.br", Aliases: []string{"www.gazeta-x.com.br"}}
+	tests := []struct {
+		name, source, origin string
+		sameOrigin           bool
+		want                 bool
+	}{
+		{name: "mesma origem", source: "https://gazeta-x.com.br", origin: "https://gazeta-x.com.br", want: true},
+		{name: "Origin em maiúsculas", source: "https://gazeta-x.com.br", origin: "HTTPS://GAZETA-X.COM.BR", want: true},
+		{name: "cache do Google", source: "https://gazeta-x.com.br", origin: "https://gazeta--x-com-br.cdn.ampproject.org", want: true},
+		{name: "cache do Bing", source: "https://www.gazeta-x.com.br", origin: "https://www-gazeta--x-com-br.bing-amp.com", want: true},
+		{name: "cache sem o hífen dobrado", source: "https://gazeta-x.com.br", origin: "https://gazeta-x-com-br.cdn.ampproject.org", want: false},
+		{name: "cache de outro publisher", source: "https://gazeta-x.com.br", origin: "https://outro-example.cdn.ampproject.org", want: false},
+		{name: "cache em http", source: "https://gazeta-x.com.br", origin: "http://gazeta--x-com-br.cdn.ampproject.org", want: false},
+		{name: "Origin de terceiro", source: "https://gazeta-x.com.br", origin: "https://evil.example", want: false},
+		{name: "source de outro tenant", source: "https://evil.example", origin: "https://evil.example", want: false},
+		{name: "source em http", source: "http://gazeta-x.com.br", origin: "http://gazeta-x.com.br", want: false},
+		{name: "sem Origin com AMP-Same-Origin", source: "https://gazeta-x.com.br", sameOrigin: true, want: true},
+		{name: "sem Origin nem AMP-Same-Origin", source: "https://gazeta-x.com.br", want: false},
+		{name: "AMP-Same-Origin com source de outro tenant", source: "https://evil.example", sameOrigin: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/amp/ads", nil)
+			if tt.origin != "" { r.Header.Set("Origin", tt.origin) }
+			if tt.sameOrigin { r.Header.Set("AMP-Same-Origin", "true") }
+			if got := ampOriginOK(r, tn, tt.source); got != tt.want { t.Errorf("ampOriginOK = %v, quer %v", got, tt.want) }
+		})
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"ads-go/internal/health"
+	"ads-go/internal/metrics"
+	mysqldb "ads-go/internal/storage/mysql"
+	"ads-go/internal/telemetry"
 	"ads-go/internal/tenant"
 )
 
@@ -31,28 +37,58 @@ type nodeResp struct {
 }
 
 type adsNodeDeps struct {
-	DB *sql.DB
+	Store Store
+	Snap  *LastGood // último catálogo bom (MySQL fora do ar)
+	Dev   bool      // modo dev: links do AMP apontam para o próprio servidor (portalURL)
+}
+
+var errDBDown = errors.New("mysql fora do ar")
+
+// catalog lê o catálogo do MySQL e guarda como último bom; com o banco fora (ou erro),
+// devolve o último bom (stale = true). Fora do ar conhecido, nem tenta: não espera timeout.
+func (d adsNodeDeps) catalog(ctx context.Context, tenantID int) (items []nodeItem, stale bool, err error) {
+	err = errDBDown
+	if d.Store.Up() {
+		if items, err = d.Store.catalog(ctx, tenantID); err == nil {
+			d.Snap.putCatalog(tenantID, items)
+			return items, false, nil
+		}
+		metrics.MySQLErrors.WithLabelValues("catalog").Inc()
+	}
+	cached, ok := d.Snap.getCatalog(tenantID)
+	metrics.CacheResult("snapshot", ok)
+	if ok {
+		slog.WarnContext(ctx, "catálogo servido do último estado bom", "err", err)
+		return cached, true, nil
+	}
+	return nil, false, err
 }
 
 // GET "/"  → JSON idêntico ao Node
 func (d adsNodeDeps) AdsRoot(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type","application/json")
-	t := tenant.FromRequestHost(r.Host, r.Header.Get("X-Forwarded-Host"))
+	t := reqTenant(r)
 
-	items, err := fetchActiveItems(r.Context(), d.DB, t.ID)
+	items, stale, err := d.catalog(r.Context(), t.ID)
 	if err != nil {
-		log.Printf("[ads root] mysql err: %v", err)
+		slog.ErrorContext(r.Context(), "ads root: erro no catálogo", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error":"internal"})
 		return
 	}
+	if stale { w.Header().Set("X-Catalog-Stale", "1") }
+	metrics.AdsServed.WithLabelValues(metrics.Tenant(t.ID), "root").Add(float64(len(items)))
 	_ = json.NewEncoder(w).Encode(nodeResp{
 		Ads: items, Redirect: t.AdsURL, Static: t.Static,
 	})
 }
 
-// Consulta simples (status=1, janela válida, não deletado)
-func fetchActiveItems(ctx context.Context, db *sql.DB, tenantID int) ([]nodeItem, error) {
+// Consulta simples (status=1, janela válida, não deletado) — vai para a réplica
+func fetchActiveItems(ctx context.Context, db *mysqldb.DB, tenantID int) (_ []nodeItem, err error) {
+	ctx, span := tracer.Start(ctx, "catalog.fetch")
+	defer func() { telemetry.SpanError(span, err); span.End() }()
+	ctx, cancel := db.ReadContext(ctx)
+	defer cancel()
 	const q = `
 		SELECT
 			code,                   -- 0
@@ -117,5 +153,31 @@ func fetchActiveItems(ctx context.Context, db *sql.DB, tenantID int) ([]nodeItem
 			Code: code, Description: desc, Breackpoint: bp, Types: typed,
 		})
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil { return nil, err }
+	lastCatalogRead.Store(time.Now().UnixNano())
+	return out, nil
+}
+
+// lastCatalogRead marca a última leitura bem-sucedida do catálogo (frescor no readiness).
+var lastCatalogRead atomic.Int64
+
+// CatalogReadAt devolve a última leitura bem-sucedida do catálogo (zero = nunca).
+func CatalogReadAt() time.Time {
+	if ns := lastCatalogRead.Load(); ns > 0 { return time.Unix(0, ns) }
+	return time.Time{}
+}
+
+// CatalogCheck informa há quanto tempo o catálogo foi lido com sucesso; se passou de maxAge
+// (ou nunca leu), faz uma leitura de prova pelo mesmo caminho das rotas (réplica ou primário).
+func CatalogCheck(store Store, maxAge time.Duration) health.CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		if at := CatalogReadAt(); !at.IsZero() {
+			if age := time.Since(at); age <= maxAge {
+				return "lido há " + age.Round(time.Second).String(), nil
+			}
+		}
+		items, err := store.catalog(ctx, tenant.Default.ID)
+		if err != nil { return "", err }
+		return "leitura de prova ok (" + strconv.Itoa(len(items)) + " anúncios)", nil
+	}
 }
@@ -0,0 +1,204 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ads-go/internal/tenant"
+)
+
+// LastGood guarda o último catálogo lido com sucesso de cada tenant e os shortlinks quentes
+// (os acessados mais recentemente), para continuar servindo com o MySQL fora do ar.
+// Vai para um arquivo de snapshot periodicamente e é lido no boot.
+type LastGood struct {
+	maxShorts int
+
+	mu      sync.RWMutex
+	catalog map[int][]nodeItem
+	shorts  map[string]hotShort // "tenant|code"
+	tenants []tenant.Tenant     // registro no momento do snapshot (TENANTS_SOURCE=mysql)
+	loaded  time.Time           // SavedAt do snapshot lido no boot (zero = não veio de snapshot)
+	ver     uint64              // sobe a cada mudança; saved = versão já gravada em disco
+	saved   uint64
+}
+
+type hotShort struct {
+	TenantID int
+	Code     string
+	Entry    shortEntry
+	Seen     time.Time
+}
+
+type snapshotFile struct {
+	SavedAt time.Time
+	Tenants []tenant.Tenant
+	Catalog map[int][]nodeItem
+	Shorts  []hotShort
+}
+
+// NewLastGood cria o cache de último estado bom; maxShorts limita os shortlinks guardados.
+func NewLastGood(maxShorts int) *LastGood {
+	return &LastGood{maxShorts: maxShorts, catalog: map[int][]nodeItem{}, shorts: map[string]hotShort{}}
+}
+
+// shortKey ignora a caixa do código, como o cache do Redis (getKey).
+func shortKey(tenantID int, code string) string { return strconv.Itoa(tenantID) + "|" + strings.ToLower(code) }
+
+// seenEvery: de quanto em quanto um hit renova o Seen (ordem da poda); abaixo disso só o RLock.
+const seenEvery = time.Minute
+
+func (g *LastGood) putCatalog(tenantID int, items []nodeItem) {
+	if g == nil { return }
+	g.mu.Lock()
+	g.catalog[tenantID] = items
+	g.ver++
+	g.mu.Unlock()
+}
+
+func (g *LastGood) getCatalog(tenantID int) ([]nodeItem, bool) {
+	if g == nil { return nil, false }
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	items, ok := g.catalog[tenantID]
+	return items, ok
+}
+
+// putShort guarda o shortlink servido. Cada hit do Redis passa aqui: o mesmo conteúdo visto há
+// pouco não pega o lock de escrita, e só conteúdo novo ou alterado marca o snapshot para gravar.
+func (g *LastGood) putShort(tenantID int, code string, e shortEntry) {
+	if g == nil { return }
+	k, now := shortKey(tenantID, code), time.Now()
+	g.mu.RLock()
+	h, ok := g.shorts[k]
+	g.mu.RUnlock()
+	if ok && sameEntry(h.Entry, e) && now.Sub(h.Seen) < seenEvery { return }
+
+	g.mu.Lock()
+	h, ok = g.shorts[k]
+	if !ok || !sameEntry(h.Entry, e) { g.ver++ }
+	g.shorts[k] = hotShort{TenantID: tenantID, Code: code, Entry: e, Seen: now}
+	if len(g.shorts) > 2*g.maxShorts { g.trimLocked() } // poda em lote, não a cada acesso
+	g.mu.Unlock()
+}
+
+func sameEntry(a, b shortEntry) bool {
+	return a.URL == b.URL && a.UUID == b.UUID && a.Description == b.Description && a.Image == b.Image && slices.Equal(a.Variants, b.Variants)
+}
+
+func (g *LastGood) getShort(tenantID int, code string) (shortEntry, bool) {
+	if g == nil { return shortEntry{}, false }
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	h, ok := g.shorts[shortKey(tenantID, code)]
+	return h.Entry, ok
+}
+
+// dropShort esquece o shortlink (alterado/removido pelo /admin).
+func (g *LastGood) dropShort(tenantID int, code string) {
+	if g == nil { return }
+	g.mu.Lock()
+	if _, ok := g.shorts[shortKey(tenantID, code)]; ok {
+		delete(g.shorts, shortKey(tenantID, code))
+		g.ver++
+	}
+	g.mu.Unlock()
+}
+
+// trimLocked mantém só os maxShorts acessados mais recentemente.
+func (g *LastGood) trimLocked() {
+	if len(g.shorts) <= g.maxShorts { return }
+	list := make([]hotShort, 0, len(g.shorts))
+	for _, h := range g.shorts { list = append(list, h) }
+	sort.Slice(list, func(i, j int) bool { return list[i].Seen.After(list[j].Seen) })
+	g.shorts = make(map[string]hotShort, g.maxShorts)
+	for _, h := range list[:g.maxShorts] { g.shorts[shortKey(h.TenantID, h.Code)] = h }
+}
+
+// Has diz se há algo para servir sem o MySQL (catálogo de algum tenant).
+func (g *LastGood) Has() bool {
+	if g == nil { return false }
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.catalog) > 0
+}
+
+// Tenants devolve o registro salvo no snapshot (vazio se não veio de snapshot).
+func (g *LastGood) Tenants() []tenant.Tenant {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.tenants
+}
+
+// Load lê o snapshot do disco (boot).
+func (g *LastGood) Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil { return err }
+	var f snapshotFile
+	if err := json.Unmarshal(b, &f); err != nil { return err }
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f.Catalog != nil { g.catalog = f.Catalog }
+	for _, h := range f.Shorts { g.shorts[shortKey(h.TenantID, h.Code)] = h }
+	g.tenants, g.loaded = f.Tenants, f.SavedAt
+	return nil
+}
+
+// LoadedAt é o SavedAt do snapshot lido no boot (zero = não veio de snapshot).
+func (g *LastGood) LoadedAt() time.Time {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.loaded
+}
+
+// Save grava o snapshot (tmp + rename: nunca deixa arquivo pela metade). Sem mudanças, não faz nada;
+// se a gravação falha, a próxima chamada tenta de novo.
+func (g *LastGood) Save(path string) error {
+	g.mu.Lock()
+	ver := g.ver
+	if ver == g.saved {
+		g.mu.Unlock()
+		return nil
+	}
+	g.trimLocked()
+	f := snapshotFile{SavedAt: time.Now(), Tenants: tenant.All(), Catalog: g.catalog, Shorts: make([]hotShort, 0, len(g.shorts))}
+	for _, h := range g.shorts { f.Shorts = append(f.Shorts, h) }
+	b, err := json.Marshal(f)
+	g.mu.Unlock()
+	if err != nil { return err }
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { return err }
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil { return err }
+	if err := os.Rename(tmp, path); err != nil { return err }
+	// só o que entrou até o Marshal está no arquivo: mudanças durante a gravação ficam para a próxima
+	g.mu.Lock()
+	if ver > g.saved { g.saved = ver }
+	g.mu.Unlock()
+	return nil
+}
+
+// StartSnapshots grava o snapshot a cada interval até ctx acabar (o último Save fica com o shutdown).
+// Só o que veio do MySQL/Redis marca mudança, então um boot pelo snapshot não o regrava igual.
+func (g *LastGood) StartSnapshots(ctx context.Context, path string, interval time.Duration) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := g.Save(path); err != nil { slog.Error("snapshot: falha ao gravar", "path", path, "err", err) }
+			}
+		}
+	}()
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLastGoodShortIgnoraCaixa(t *testing.T) {
+	g := NewLastGood(10)
+	g.putShort(1, "AbC", shortEntry{URL: "https://a.example"})
+	if _, ok := g.getShort(1, "abc"); !ok { t.Fatal("getShort(abc) não achou AbC") }
+	g.dropShort(1, "ABC")
+	if _, ok := g.getShort(1, "AbC"); ok { t.Fatal("dropShort(ABC) não esqueceu AbC") }
+}
+
+func TestLastGoodSoMarcaMudanca(t *testing.T) {
+	g := NewLastGood(10)
+	e := shortEntry{URL: "https://a.example", Variants: []shortVariant{{Key: "a", URL: "https://a.example", Weight: 1}}}
+	g.putShort(1, "x", e)
+	v := g.ver
+	g.putShort(1, "x", e)
+	if g.ver != v { t.Errorf("hit com o mesmo conteúdo marcou mudança") }
+	e.URL = "https://b.example"
+	g.putShort(1, "x", e)
+	if g.ver == v { t.Errorf("conteúdo novo não marcou mudança") }
+}
+
+func TestLastGoodSaveFalhaMantemPendente(t *testing.T) {
+	dir := t.TempDir()
+	g := NewLastGood(10)
+	g.putShort(1, "x", shortEntry{URL: "https://a.example"})
+
+	// diretório do snapshot é um arquivo: a gravação falha e a mudança continua pendente
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil { t.Fatal(err) }
+	if err := g.Save(filepath.Join(blocker, "snap.json")); err == nil { t.Fatal("Save num caminho inválido não falhou") }
+
+	path := filepath.Join(dir, "snap.json")
+	if err := g.Save(path); err != nil { t.Fatal(err) }
+	if _, err := os.Stat(path); err != nil { t.Fatalf("snapshot não gravado depois da falha: %v", err) }
+
+	h := NewLastGood(10)
+	if err := h.Load(path); err != nil { t.Fatal(err) }
+	if e, ok := h.getShort(1, "X"); !ok || e.URL != "https://a.example" { t.Errorf("snapshot relido: %+v, %v", e, ok) }
+}
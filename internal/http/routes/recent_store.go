@@ -11,9 +11,9 @@ import (
 
 // Redis
 
-type redisRecent struct { rdb *redis.Client }
+type redisRecent struct { rdb redis.UniversalClient }
 
-func NewRedisRecent(rdb *redis.Client) *redisRecent { return &redisRecent{rdb: rdb} }
+func NewRedisRecent(rdb redis.UniversalClient) *redisRecent { return &redisRecent{rdb: rdb} }
 
 func (s *redisRecent) Get(r *http.Request, tenantID int, userKey string, n int) ([]string, error) {
 	key := recentKey(tenantID, userKey)
@@ -1,24 +1,81 @@
 package routes
 
 import (
-	"database/sql"
+	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/redis/go-redis/v9"
 
 	"ads-go/internal/ads"
 	"ads-go/internal/config"
+	"ads-go/internal/linkcheck"
+	"ads-go/internal/safeurl"
+	"ads-go/internal/spool"
+	mysqldb "ads-go/internal/storage/mysql"
 )
 
-func Register(r *chi.Mux, cfg config.Config, rdb *redis.Client, db *sql.DB) {
+// Deps são as dependências das rotas, montadas no main.
+type Deps struct {
+	Cfg    config.Config         // snapshot do boot
+	Conf   *config.Store         // valores recarregáveis (SIGHUP)
+	Rdb    redis.UniversalClient // nil = sem Redis
+	DB     *mysqldb.DB           // nil no modo dev; /admin e /_reports/db precisam dele
+	Store  Store                 // catálogo, shortlinks e cliques (MySQL ou memória)
+	Links  *linkcheck.Checker    // nil = verificador desligado
+	Guard  *safeurl.Checker
+	Snap   *LastGood    // último catálogo/shortlinks bons (snapshot em disco)
+	Clicks *spool.Spool // fila em disco dos cliques; nil = sem fila
+
+	// RateLimit devolve o middleware de rate limit do grupo ("ads", "short", "qr", "admin"); nil = sem limite
+	RateLimit func(group string) func(http.Handler) http.Handler
+}
+
+func (d Deps) limit(group string) func(http.Handler) http.Handler {
+	if d.RateLimit == nil { return func(next http.Handler) http.Handler { return next } }
+	return d.RateLimit(group)
+}
+
+func Register(r chi.Router, d Deps) {
 	// repo/caches originais seguem intocados (usados por outras rotas internas)
-	_ = ads.NewMySQLRepo  // garante link do pacote ads, se usar em outros pontos
+	_ = ads.NewMySQLRepo // garante link do pacote ads, se usar em outros pontos
 
 	// Raiz "/" no formato do Node
-	node := adsNodeDeps{DB: db}
-	r.Get("/", node.AdsRoot)
+	node := adsNodeDeps{Store: d.Store, Snap: d.Snap, Dev: d.Cfg.DevMode}
+	r.With(d.limit("ads")).Get("/", node.AdsRoot)
+	r.With(d.limit("ads")).Get("/amp/ads", node.AMP) // amp-list/amp-ad (protocolo CORS do AMP)
 
 	// Shortlink
-	sd := shortDeps{Cfg: cfg, Rdb: rdb, DB: db}
-	r.Get("/{short}", sd.Short)
+	sd := shortDeps{Cfg: d.Cfg, Rdb: d.Rdb, Store: d.Store, Guard: d.Guard, Snap: d.Snap, Clicks: d.Clicks}
+	r.With(d.limit("qr")).Get("/{short}.png", sd.QR)
+	r.With(d.limit("qr")).Get("/{short}.svg", sd.QR)
+	r.With(d.limit("short")).Get("/{short}", sd.Short)
+
+	// Preflight: o chi só roda o middleware do grupo (Tenant, CORS) em rota e método registrados;
+	// sem o OPTIONS aqui o preflight recebia 405 antes do CORS. Quem responde é o CORS (204).
+	for _, p := range []string{"/", "/amp/ads", "/{short}", "/{short}.png", "/{short}.svg"} {
+		r.Options(p, preflight)
+	}
+}
+
+// preflight só é alcançado sem o middleware CORS na frente (ele já responde o OPTIONS).
+func preflight(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+// RegisterReports monta os relatórios internos (/links, /db) sob /_reports; a autenticação
+// (middleware.RequireAPIKey) fica com quem monta o grupo.
+func RegisterReports(r chi.Router, d Deps) {
+	rd := reportDeps{Conf: d.Conf, Links: d.Links, DB: d.DB}
+	r.Get("/links", rd.FailingLinks)
+	r.Get("/db", rd.DBStats)
+}
+
+// RegisterAdmin monta a API de escrita (POST/PATCH/DELETE) sob /admin; a autenticação
+// (middleware.AdminAuth) e o rate limit ficam com quem monta o grupo.
+func RegisterAdmin(r chi.Router, d Deps) {
+	ad := adminDeps{DB: d.DB, Rdb: d.Rdb, Guard: d.Guard, Snap: d.Snap}
+	r.Route("/tenants/{tenant}/ads", func(r chi.Router) {
+		r.Post("/", ad.CreateAd)
+		r.Get("/{code}", ad.GetAd)
+		r.Patch("/{code}", ad.UpdateAd)
+		r.Delete("/{code}", ad.DeleteAd)
+	})
 }
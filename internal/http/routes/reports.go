@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"ads-go/internal/config"
+	"ads-go/internal/linkcheck"
+	mysqldb "ads-go/internal/storage/mysql"
+)
+
+type reportDeps struct {
+	Conf  *config.Store
+	Links *linkcheck.Checker
+	DB    *mysqldb.DB
+}
+
+// GET /_reports/links[?min_age=24h] → destinos falhando há pelo menos min_age (padrão LINKCHECK_FAIL_AFTER)
+func (d reportDeps) FailingLinks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if d.Links == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "linkcheck desligado"})
+		return
+	}
+	minAge := d.Conf.Get().LinkCheckFailAfter
+	if v, err := time.ParseDuration(r.URL.Query().Get("min_age")); err == nil && v >= 0 {
+		minAge = v
+	}
+	failing := d.Links.Failing(minAge)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"last_run": d.Links.LastRun(),
+		"min_age":  minAge.String(),
+		"count":    len(failing),
+		"failing":  failing,
+	})
+}
+
+// GET /_reports/db → estatísticas dos pools (primário e réplicas, com a saúde de cada uma)
+func (d reportDeps) DBStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if d.DB == nil { // modo dev
+		_ = json.NewEncoder(w).Encode(map[string]any{"pools": []any{}, "dev_mode": true})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"pools": d.DB.Stats()})
+}
@@ -5,24 +5,34 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/go-sql-driver/mysql"
 	"github.com/redis/go-redis/v9"
 
 	"ads-go/internal/config"
+	"ads-go/internal/metrics"
+	"ads-go/internal/safeurl"
+	"ads-go/internal/spool"
+	mysqldb "ads-go/internal/storage/mysql"
+	"ads-go/internal/telemetry"
 	"ads-go/internal/tenant"
 )
 
+var tracer = telemetry.Tracer("routes")
+
 type shortDeps struct {
-	Cfg config.Config
-	Rdb *redis.Client
-	DB  *sql.DB
+	Cfg    config.Config
+	Rdb    redis.UniversalClient
+	Store  Store
+	Guard  *safeurl.Checker
+	Snap   *LastGood    // shortlinks quentes (MySQL fora do ar)
+	Clicks *spool.Spool // fila em disco dos cliques que não chegaram no MySQL (nil = descarta)
 }
 
 func (d shortDeps) getKey(t tenant.Tenant, short string) string {
@@ -33,48 +43,67 @@ func (d shortDeps) nfKey(cacheKey string) string {
 	return "nf:" + cacheKey // negative cache key
 }
 
-func (d shortDeps) lookupShort(w http.ResponseWriter, r *http.Request) (string, string, error) {
-	short := chi.URLParam(r, "short")
+// shortEntry é o que fica no cache positivo do Redis (campos URL/UUID mantidos p/ compatibilidade).
+type shortEntry struct {
+	URL         string
+	UUID        string
+	Description string         `json:",omitempty"`
+	Image       string         `json:",omitempty"` // "file.ext" relativo ao Static do tenant (cards OG)
+	Variants    []shortVariant `json:",omitempty"`
+}
+
+func (d shortDeps) lookupShort(r *http.Request, t tenant.Tenant, short string) (shortEntry, error) {
 	if short == "" {
-		return "", "", errors.New("empty")
+		return shortEntry{}, errors.New("empty")
 	}
-	t := tenant.FromRequestHost(r.Host, r.Header.Get("X-Forwarded-Host"))
 	cacheKey := d.getKey(t, short)
+	ctx, span := tracer.Start(r.Context(), "short.lookup")
+	defer span.End()
+	r = r.WithContext(ctx)
 
 	// 0) Negative cache (evita bater no MySQL repetidamente por 4 minutos)
 	if d.Rdb != nil {
 		if _, err := d.Rdb.Get(r.Context(), d.nfKey(cacheKey)).Result(); err == nil {
 			// marcado como não encontrado recentemente
-			return "", "", errors.New("not found")
+			metrics.CacheResult("negative", true)
+			return shortEntry{}, errors.New("not found")
 		}
+		metrics.CacheResult("negative", false)
 	}
 
 	// 1) Tenta Redis (cache positivo)
 	if d.Rdb != nil {
 		if raw, err := d.Rdb.Get(r.Context(), cacheKey).Result(); err == nil {
-			var v struct{ URL, UUID string }
+			var v shortEntry
 			if json.Unmarshal([]byte(raw), &v) == nil {
-				return v.URL, v.UUID, nil
+				metrics.CacheResult("redis", true)
+				d.Snap.putShort(t.ID, short, v)
+				return v, nil
 			}
 		}
+		metrics.CacheResult("redis", false)
 	}
 
-	// 2) Busca no MySQL
-	if d.DB != nil {
-		if url, uuid, id, ok, err := fetchShortFromMySQL(r.Context(), d.DB, t.ID, short); err == nil && ok {
-			_ = id // evitar "declared and not used" até você usar o id
+	// 2) Busca no MySQL (fora do ar conhecido: direto para o último estado bom)
+	if d.Store != nil && !d.Store.Up() {
+		return d.lastGoodShort(r, t, short, errDBDown)
+	}
+	if d.Store != nil {
+		if e, ok, err := d.Store.short(r.Context(), t.ID, short); err == nil && ok {
 			// 2.a) Achou: coloca no Redis (cache positivo) e retorna
 			if d.Rdb != nil {
-				b, _ := json.Marshal(map[string]string{"URL": url, "UUID": uuid})
+				b, _ := json.Marshal(e)
 				_ = d.Rdb.Set(r.Context(), cacheKey, string(b), 24*time.Hour).Err()
 				// garante que a flag negativa não atrapalhe um hit recém inserido
 				_ = d.Rdb.Del(r.Context(), d.nfKey(cacheKey)).Err()
 			}
-			return url, uuid, nil
+			d.Snap.putShort(t.ID, short, e)
+			return e, nil
 		} else if err != nil {
 			// erro real de MySQL — loga e não seta negative cache (para não esconder problema)
-			log.Printf("short mysql error: %v", err)
-			return "", "", err
+			slog.ErrorContext(r.Context(), "short: erro no mysql", "err", err)
+			metrics.MySQLErrors.WithLabelValues("short_lookup").Inc()
+			return d.lastGoodShort(r, t, short, err)
 		}
 	}
 
@@ -82,57 +111,130 @@ func (d shortDeps) lookupShort(w http.ResponseWriter, r *http.Request) (string,
 	if d.Rdb != nil {
 		_ = d.Rdb.Set(r.Context(), d.nfKey(cacheKey), "1", 4*time.Minute).Err()
 	}
-	return "", "", errors.New("not found")
+	return shortEntry{}, errors.New("not found")
+}
+
+// lastGoodShort: sem MySQL, serve o shortlink do conjunto quente (snapshot); senão devolve err.
+func (d shortDeps) lastGoodShort(r *http.Request, t tenant.Tenant, short string, err error) (shortEntry, error) {
+	e, ok := d.Snap.getShort(t.ID, short)
+	metrics.CacheResult("snapshot", ok)
+	if !ok { return shortEntry{}, err }
+	slog.WarnContext(r.Context(), "short servido do último estado bom", "short", short, "err", err)
+	return e, nil
 }
 
 func (d shortDeps) Short(w http.ResponseWriter, r *http.Request) {
-	t := tenant.FromRequestHost(r.Host, r.Header.Get("X-Forwarded-Host"))
+	t := reqTenant(r)
 
-	redir, uuid, err := d.lookupShort(w, r)
+	short, preview := previewCode(r)
+	e, err := d.lookupShort(r, t, short)
 	if err != nil {
-		http.Redirect(w, r, "https://"+t.Portal+"?short_error=404", http.StatusFound)
+		metrics.Redirects.WithLabelValues(metrics.Tenant(t.ID), "not_found").Inc()
+		http.Redirect(w, r, portalURL(r, t, d.Cfg.DevMode)+"?short_error=404", http.StatusFound)
+		return
+	}
+
+	// Teste A/B: escolhe o destino (sticky por cookie ou hash IP+UA). Prévia e crawlers veem o destino padrão.
+	bot := isUnfurlBot(r.UserAgent())
+	redir, variant := e.URL, ""
+	if !preview && !bot {
+		if v, ok := pickVariant(r, short, e.Variants); ok {
+			redir, variant = v.URL, v.Key
+		}
+	}
+
+	portal := portalURL(r, t, d.Cfg.DevMode)
+
+	// Destino revalidado a cada redirect (dado do banco/cache pode estar adulterado)
+	if err := d.Guard.Check(t.ID, redir); err != nil {
+		slog.WarnContext(r.Context(), "short: destino bloqueado", "short", short, "url", redir, "err", err)
+		metrics.Redirects.WithLabelValues(metrics.Tenant(t.ID), "blocked").Inc()
+		renderBlocked(w, t, portal)
 		return
 	}
+	if variant != "" { setVariantCookie(w, r, short, variant) }
 
-	// Salva o clique aqui mesmo (como no Node)
-	if d.DB != nil {
-		if err := salvarClick(d.DB, uuid, t.ID, clientIP(r), r.UserAgent(), r.Referer()); err != nil {
-			log.Printf("short click save error: %v", err)
+	// Prévia: só mostra o destino, sem registrar clique
+	if preview {
+		metrics.Redirects.WithLabelValues(metrics.Tenant(t.ID), "preview").Inc()
+		renderPreview(w, t, portal, short, e)
+		return
+	}
+
+	// Crawlers de preview (WhatsApp, Facebook...) recebem o card OG e não contam como clique
+	if bot {
+		metrics.Redirects.WithLabelValues(metrics.Tenant(t.ID), "unfurl").Inc()
+		renderUnfurl(w, t, portal, short, e)
+		return
+	}
+
+	// Salva o clique aqui mesmo (como no Node); sem MySQL, vai para a fila em disco
+	if d.Store != nil {
+		c := clickLog{
+			UUID: e.UUID, TenantID: t.ID, IP: clientIP(r), UA: r.UserAgent(), Referer: r.Referer(),
+			Variant: variant, Source: clickSource(r), At: time.Now(),
+		}
+		err := errDBDown
+		if d.Store.Up() {
+			if err = d.Store.saveClick(r.Context(), c); err != nil {
+				slog.ErrorContext(r.Context(), "short: erro ao gravar clique", "err", err)
+				metrics.MySQLErrors.WithLabelValues("click_insert").Inc()
+			}
+		}
+		switch {
+		case errors.Is(err, errClickInvalid):
+			metrics.EventsDropped.WithLabelValues("invalid").Inc() // nunca vai entrar: não envenena a fila
+		case err != nil:
+			d.spoolClick(r, c)
 		}
 	}
 
+	metrics.Redirects.WithLabelValues(metrics.Tenant(t.ID), "redirect").Inc()
 	w.Header().Set("Cache-Control", "no-store")
 	http.Redirect(w, r, redir, http.StatusFound)
 }
 
 // --- helpers ---
 
-func fetchShortFromMySQL(ctx context.Context, db *sql.DB, tenantID int, short string) (url string, uuid string, id int, ok bool, err error) {
+// reqTenant lê o tenant resolvido pelo middleware.Tenant; sem middleware, resolve pelo host.
+func reqTenant(r *http.Request) tenant.Tenant {
+	if t, ok := tenant.FromContext(r.Context()); ok { return t }
+	return tenant.FromRequestHost(r.Host, r.Header.Get("X-Forwarded-Host"))
+}
+
+// fetchShortFromMySQL lê numa réplica; primary força o primário.
+func fetchShortFromMySQL(ctx context.Context, db *mysqldb.DB, tenantID int, short string, primary bool) (e shortEntry, id int, ok bool, err error) {
+	ctx, cancel := db.ReadContext(ctx)
+	defer cancel()
 	// Ajuste a tabela/colunas conforme seu schema
+	// variants (JSON, opcional): [{"key":"a","url":"https://...","weight":50}, ...]
 	const q = `
-		SELECT redirect, uuid, id
+		SELECT redirect, uuid, id, COALESCE(description,''), types, variants
 		FROM ads
 		WHERE tenant_id = ? AND code = ? AND deleted_at IS NULL
 		LIMIT 1
 	`
-	err = db.QueryRowContext(ctx, q, tenantID, short).Scan(&url, &uuid, &id)
+	queryRow := db.QueryRowContext
+	if primary { queryRow = db.QueryRowPrimaryContext }
+	var typesJSON, variantsJSON sql.NullString
+	err = queryRow(ctx, q, tenantID, short).Scan(&e.URL, &e.UUID, &id, &e.Description, &typesJSON, &variantsJSON)
 	if err == sql.ErrNoRows {
-		return "", "", 0, false, nil
+		return shortEntry{}, 0, false, nil
 	}
 	if err != nil {
-		return "", "", 0, false, err
+		return shortEntry{}, 0, false, err
+	}
+	if typesJSON.Valid {
+		e.Image = shortImage(typesJSON.String)
+	}
+	if variantsJSON.Valid {
+		e.Variants = parseVariants(variantsJSON.String)
 	}
-	return url, uuid, id, true, nil
+	return e, id, true, nil
 }
 
+// clientIP: RemoteAddr já vem resolvido pelo middleware.RealIP (headers só de proxies confiáveis).
 func clientIP(r *http.Request) string {
-	if ip := strings.TrimSpace(r.Header.Get("cf-connecting-ip")); ip != "" {
-		return ip
-	}
-	if fwd := r.Header.Get("x-forwarded-for"); fwd != "" {
-		ip := strings.TrimSpace(strings.Split(fwd, ",")[0])
-		return ip
-	}
 	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err == nil && host != "" {
 		return host
@@ -140,12 +242,99 @@ func clientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
-func salvarClick(db *sql.DB, uuid string, tenantID int, ip, ua, ref string) error {
+// clickLog é uma linha de ads_logs.
+type clickLog struct {
+	UUID     string
+	TenantID int
+	IP       string
+	UA       string
+	Referer  string
+	Variant  string    // chave do destino A/B ("" = sem teste)
+	Source   string    // origem do clique, ex.: "qr" ("" = link comum)
+	At       time.Time // hora do clique (na fila em disco, a gravação vem depois)
+}
+
+// spoolClick enfileira o clique em disco até o MySQL voltar.
+func (d shortDeps) spoolClick(r *http.Request, c clickLog) {
+	if d.Clicks == nil {
+		metrics.EventsDropped.WithLabelValues("no_queue").Inc()
+		return
+	}
+	if err := d.Clicks.Append(c); err != nil {
+		slog.ErrorContext(r.Context(), "short: clique descartado (fila em disco)", "err", err)
+		reason := "queue_error"
+		if errors.Is(err, spool.ErrFull) { reason = "queue_full" }
+		metrics.EventsDropped.WithLabelValues(reason).Inc()
+	}
+}
+
+// ReplayClick grava um clique da fila em disco (usado por spool.Start). Linha corrompida ou
+// recusada pelo MySQL (errClickInvalid) é descartada para não travar a fila; os demais erros
+// mantêm o clique para a próxima volta.
+func ReplayClick(store Store) func(ctx context.Context, line json.RawMessage) error {
+	return func(ctx context.Context, line json.RawMessage) error {
+		var c clickLog
+		if err := json.Unmarshal(line, &c); err != nil {
+			slog.Error("spool: clique corrompido descartado", "err", err)
+			metrics.EventsDropped.WithLabelValues("corrupt").Inc()
+			return nil
+		}
+		err := store.saveClick(ctx, c)
+		if errors.Is(err, errClickInvalid) {
+			slog.Error("spool: clique recusado pelo mysql descartado", "uuid", c.UUID, "err", err)
+			metrics.EventsDropped.WithLabelValues("invalid").Inc()
+			return nil
+		}
+		return err
+	}
+}
+
+// clickSource lê ?src= (ex.: "qr"); só aceita [a-z0-9_-] com até 32 chars para não poluir os relatórios.
+func clickSource(r *http.Request) string {
+	src := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("src")))
+	if src == "" || len(src) > 32 { return "" }
+	for _, c := range src {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' && c != '-' { return "" }
+	}
+	return src
+}
+
+func nullIfEmpty(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
+
+// errClickInvalid: o MySQL recusou o dado em si; tentar de novo dá o mesmo erro.
+var errClickInvalid = errors.New("clique inválido")
+
+// erros de dado do MySQL (modo estrito): 1406 Data too long, 1366 Incorrect string value, 1292 Incorrect datetime
+func permanentClickError(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && (me.Number == 1406 || me.Number == 1366 || me.Number == 1292) {
+		return errors.Join(errClickInvalid, err)
+	}
+	return err
+}
+
+// column corta s em n caracteres (VARCHAR conta caracteres) e troca UTF-8 inválido.
+func column(s string, n int) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if r := []rune(s); len(r) > n { s = string(r[:n]) }
+	return s
+}
+
+// salvarClick grava no primário (ExecContext nunca vai para réplica).
+func salvarClick(ctx context.Context, db *mysqldb.DB, c clickLog) (err error) {
+	ctx, span := tracer.Start(ctx, "click.write")
+	defer func() { telemetry.SpanError(span, err); span.End() }()
+	ctx, cancel := db.WriteContext(ctx)
+	defer cancel()
 	// Ajuste a tabela/colunas para seu esquema real
 	const q = `
-		INSERT INTO ads_logs (uuid, tenant_id, ip, user_agent, referer, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO ads_logs (uuid, tenant_id, ip, user_agent, referer, variant, source, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := db.Exec(q, uuid, tenantID, ip, ua, ref, time.Now())
-	return err
+	at := c.At
+	if at.IsZero() { at = time.Now() }
+	// tamanhos das colunas de ads_logs: UA/Referer vêm do cliente e não podem travar o INSERT
+	_, err = db.ExecContext(ctx, q, c.UUID, c.TenantID, column(c.IP, 45), column(c.UA, 512), column(c.Referer, 2048),
+		nullIfEmpty(column(c.Variant, 64)), nullIfEmpty(column(c.Source, 32)), at)
+	return permanentClickError(err)
 }
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"html/template"
+	"net/http"
+
+	"ads-go/internal/tenant"
+)
+
+var blockedTmpl = template.Must(template.New("blocked").Parse(`<!doctype html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>Link bloqueado — {{.Portal}}</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f4f4f4;margin:0;padding:2rem;color:#222}
+main{max-width:36rem;margin:0 auto;background:#fff;border-radius:8px;padding:1.5rem;border-top:4px solid #c62828}
+a{color:#222}
+</style>
+</head>
+<body>
+<main>
+<h1>Link bloqueado</h1>
+<p>Este link leva para um endereço que não passou na nossa verificação de segurança e foi bloqueado.</p>
+<p><a href="{{.PortalURL}}">Voltar para {{.Portal}}</a></p>
+</main>
+</body>
+</html>
+`))
+
+// renderBlocked mostra o aviso de destino bloqueado (sem revelar a URL).
+func renderBlocked(w http.ResponseWriter, t tenant.Tenant, portal string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.WriteHeader(http.StatusForbidden)
+	_ = blockedTmpl.Execute(w, struct{ Portal, PortalURL string }{t.Portal, portal})
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"ads-go/internal/tenant"
+)
+
+// previewCode extrai o código do shortlink e diz se é uma prévia:
+// "/{short}+" ou "/{short}?preview=1".
+func previewCode(r *http.Request) (string, bool) {
+	short := chi.URLParam(r, "short")
+	if strings.HasSuffix(short, "+") {
+		return strings.TrimSuffix(short, "+"), true
+	}
+	return short, r.URL.Query().Get("preview") == "1"
+}
+
+type previewData struct {
+	Portal      string
+	PortalURL   string
+	Static      string
+	Short       string
+	Domain      string
+	URL         string
+	Description string
+}
+
+var previewTmpl = template.Must(template.New("preview").Parse(`<!doctype html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>Prévia do link — {{.Portal}}</title>
+<link rel="icon" href="{{.Static}}/favicon.ico">
+<style>
+body{font-family:system-ui,sans-serif;background:#f4f4f4;margin:0;padding:2rem;color:#222}
+main{max-width:36rem;margin:0 auto;background:#fff;border-radius:8px;padding:1.5rem;box-shadow:0 1px 3px rgba(0,0,0,.1)}
+header a{color:#555;text-decoration:none;font-size:.9rem}
+.domain{font-size:1.4rem;font-weight:600;margin:.5rem 0}
+.url{word-break:break-all;font-family:monospace;background:#f4f4f4;padding:.5rem;border-radius:4px}
+.go{display:inline-block;margin-top:1rem;padding:.6rem 1rem;background:#222;color:#fff;border-radius:4px;text-decoration:none}
+</style>
+</head>
+<body>
+<main>
+<header><a href="{{.PortalURL}}">{{.Portal}}</a></header>
+<p>O link <strong>/{{.Short}}</strong> leva para:</p>
+<p class="domain">{{.Domain}}</p>
+<p class="url">{{.URL}}</p>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<a class="go" href="{{.URL}}" rel="nofollow noopener">Continuar para o site</a>
+</main>
+</body>
+</html>
+`))
+
+// renderPreview mostra a página de prévia do tenant. Não grava clique.
+func renderPreview(w http.ResponseWriter, t tenant.Tenant, portal, short string, e shortEntry) {
+	domain := e.URL
+	if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+		domain = u.Hostname()
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	err := previewTmpl.Execute(w, previewData{
+		Portal: t.Portal, PortalURL: portal, Static: t.Static,
+		Short: short, Domain: domain, URL: e.URL, Description: e.Description,
+	})
+	if err != nil {
+		slog.Error("short preview: erro no template", "tenant", t.ID, "short", short, "err", err)
+	}
+}
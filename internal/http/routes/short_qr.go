@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"rsc.io/qr"
+
+	"ads-go/internal/tenant"
+)
+
+// Parâmetros aceitos em /{short}.png e /{short}.svg
+const (
+	qrDefaultSize   = 256 // px (png) / viewport (svg)
+	qrMinSize       = 64
+	qrMaxSize       = 2048
+	qrDefaultMargin = 4 // módulos de "quiet zone" (o padrão do QR pede 4)
+	qrMaxMargin     = 16
+	qrCacheControl  = "public, max-age=2592000, immutable" // 30 dias: conteúdo só depende de host+código+params
+)
+
+// qrSource marca o clique vindo de um QR impresso (?src=qr) para os relatórios.
+const qrSource = "qr"
+
+// portalURL é a origem (esquema + host) do portal do tenant. No modo dev o servidor roda em
+// http://localhost:porta, então vale o esquema e o host:porta da própria requisição.
+func portalURL(r *http.Request, t tenant.Tenant, dev bool) string {
+	if !dev { return "https://" + t.Portal }
+	scheme := "http"
+	if r.TLS != nil { scheme = "https" }
+	return scheme + "://" + r.Host
+}
+
+// canonicalShortURL é a URL pública do shortlink no portal (portalURL).
+func canonicalShortURL(portal, short string) string {
+	return portal + "/" + url.PathEscape(short)
+}
+
+func parseQRLevel(s string) qr.Level {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "L":
+		return qr.L
+	case "Q":
+		return qr.Q
+	case "H":
+		return qr.H
+	default:
+		return qr.M
+	}
+}
+
+func queryInt(r *http.Request, key string, def, min, max int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil { return def }
+	if v < min { return min }
+	if v > max { return max }
+	return v
+}
+
+// QR gera o QR code (PNG ou SVG, pela extensão da rota) do shortlink canônico do tenant.
+// Query: size (px), margin (módulos), ec (L|M|Q|H).
+func (d shortDeps) QR(w http.ResponseWriter, r *http.Request) {
+	t := reqTenant(r)
+	short := chi.URLParam(r, "short")
+
+	// só gera para códigos que existem (evita QR de lixo / enumeração barata)
+	if _, err := d.lookupShort(r, t, short); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	code, err := qr.Encode(canonicalShortURL(portalURL(r, t, d.Cfg.DevMode), short)+"?src="+qrSource, parseQRLevel(r.URL.Query().Get("ec")))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "short qr: erro ao codificar", "err", err)
+		http.Error(w, "erro ao gerar QR", http.StatusInternalServerError)
+		return
+	}
+	size := queryInt(r, "size", qrDefaultSize, qrMinSize, qrMaxSize)
+	margin := queryInt(r, "margin", qrDefaultMargin, 0, qrMaxMargin)
+
+	var (
+		body  []byte
+		ctype string
+	)
+	if strings.HasSuffix(r.URL.Path, ".svg") {
+		body, ctype = qrSVG(code, size, margin), "image/svg+xml"
+	} else {
+		if body, err = qrPNG(code, size, margin); err != nil {
+			slog.ErrorContext(r.Context(), "short qr: erro no png", "err", err)
+			http.Error(w, "erro ao gerar QR", http.StatusInternalServerError)
+			return
+		}
+		ctype = "image/png"
+	}
+
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Cache-Control", qrCacheControl)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	_, _ = w.Write(body)
+}
+
+// qrPNG rasteriza o código; cada módulo vira um bloco inteiro de pixels (sem blur),
+// então o tamanho final é o maior múltiplo que cabe em size.
+func qrPNG(code *qr.Code, size, margin int) ([]byte, error) {
+	modules := code.Size + 2*margin
+	scale := size / modules
+	if scale < 1 { scale = 1 }
+	dim := modules * scale
+
+	img := image.NewPaletted(image.Rect(0, 0, dim, dim), color.Palette{color.White, color.Black})
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) { continue }
+			x0, y0 := (x+margin)*scale, (y+margin)*scale
+			for py := y0; py < y0+scale; py++ {
+				for px := x0; px < x0+scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil { return nil, err }
+	return buf.Bytes(), nil
+}
+
+// qrSVG desenha um único <path> com um quadrado por módulo escuro (viewBox em módulos).
+func qrSVG(code *qr.Code, size, margin int) []byte {
+	modules := code.Size + 2*margin
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+margin, y+margin)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// shortVariant é um destino alternativo de um shortlink (teste A/B).
+type shortVariant struct {
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// cookie sticky: mantém o visitante no mesmo destino por 30 dias
+const variantCookieMaxAge = 30 * 24 * 60 * 60
+
+func variantCookieName(short string) string { return "ab_" + strings.ToLower(short) }
+
+// parseVariants lê a coluna JSON ads.variants; entradas inválidas ou com peso <= 0 são ignoradas.
+func parseVariants(raw string) []shortVariant {
+	raw = strings.TrimSpace(raw)
+	if raw == "" { return nil }
+	var in []shortVariant
+	if err := json.Unmarshal([]byte(raw), &in); err != nil { return nil }
+	out := make([]shortVariant, 0, len(in))
+	for _, v := range in {
+		v.Key, v.URL = strings.TrimSpace(v.Key), strings.TrimSpace(v.URL)
+		if v.Key == "" || v.URL == "" || v.Weight <= 0 { continue }
+		out = append(out, v)
+	}
+	return out
+}
+
+// pickVariant escolhe o destino do visitante. Ordem:
+//  1. cookie ab_<short> com uma chave ainda existente
+//  2. hash estável de IP+UA+short sobre a soma dos pesos (refresh cai no mesmo destino)
+//
+// Retorna ok=false quando o shortlink não tem variantes. O cookie só é gravado (setVariantCookie)
+// depois que o destino escolhido passa pelo safeurl.
+func pickVariant(r *http.Request, short string, variants []shortVariant) (shortVariant, bool) {
+	if len(variants) == 0 { return shortVariant{}, false }
+
+	name := variantCookieName(short)
+	if c, err := r.Cookie(name); err == nil {
+		for _, v := range variants {
+			if v.Key == c.Value { return v, true }
+		}
+	}
+
+	total := 0
+	for _, v := range variants { total += v.Weight }
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(clientIP(r) + "|" + r.UserAgent() + "|" + strings.ToLower(short)))
+	bucket := int(h.Sum64() % uint64(total))
+
+	chosen := variants[len(variants)-1]
+	for _, v := range variants {
+		if bucket < v.Weight { chosen = v; break }
+		bucket -= v.Weight
+	}
+
+	return chosen, true
+}
+
+// setVariantCookie fixa o visitante na variante key do shortlink.
+func setVariantCookie(w http.ResponseWriter, r *http.Request, short, key string) {
+	http.SetCookie(w, &http.Cookie{
+		Name: variantCookieName(short), Value: key, Path: "/", MaxAge: variantCookieMaxAge,
+		HttpOnly: true, Secure: r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
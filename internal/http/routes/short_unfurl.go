@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ads-go/internal/tenant"
+)
+
+// Trechos de User-Agent dos robôs que montam o card de preview ao colar um link.
+// Buscadores (Googlebot etc.) ficam de fora: para eles o 302 é o correto.
+var unfurlBots = []string{
+	"facebookexternalhit", "facebot", "meta-externalagent",
+	"whatsapp", "twitterbot", "linkedinbot", "slackbot-linkexpanding",
+	"telegrambot", "discordbot", "skypeuripreview", "pinterest",
+	"redditbot", "embedly", "vkshare", "bitlybot", "mastodon",
+}
+
+func isUnfurlBot(ua string) bool {
+	ua = strings.ToLower(ua)
+	if ua == "" { return false }
+	for _, b := range unfurlBots {
+		if strings.Contains(ua, b) { return true }
+	}
+	return false
+}
+
+// shortImage escolhe a arte do card a partir do JSON ads.types ({"3":{"file":"...","extension":"png"}}):
+// o menor tipo com arquivo. Retorna "file.ext" ou "".
+func shortImage(typesJSON string) string {
+	var raw map[string]struct {
+		File      string `json:"file"`
+		Extension string `json:"extension"`
+	}
+	if err := json.Unmarshal([]byte(typesJSON), &raw); err != nil { return "" }
+	keys := make([]int, 0, len(raw))
+	files := make(map[int]string, len(raw))
+	for k, v := range raw {
+		tp, err := strconv.Atoi(k)
+		file, ext := strings.TrimSpace(v.File), strings.TrimSpace(v.Extension)
+		if err != nil || file == "" { continue }
+		if ext != "" { file += "." + ext }
+		keys = append(keys, tp)
+		files[tp] = file
+	}
+	if len(keys) == 0 { return "" }
+	sort.Ints(keys)
+	return files[keys[0]]
+}
+
+type unfurlData struct {
+	SiteName    string
+	Title       string
+	Description string
+	Image       string
+	URL         string // URL canônica do shortlink (og:url)
+	Dest        string
+}
+
+var unfurlTmpl = template.Must(template.New("unfurl").Parse(`<!doctype html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta name="robots" content="noindex">
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="{{.SiteName}}">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:url" content="{{.URL}}">
+{{if .Description}}<meta property="og:description" content="{{.Description}}">
+<meta name="description" content="{{.Description}}">
+{{end}}{{if .Image}}<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:image" content="{{.Image}}">
+{{else}}<meta name="twitter:card" content="summary">
+{{end}}<meta name="twitter:title" content="{{.Title}}">
+{{if .Description}}<meta name="twitter:description" content="{{.Description}}">
+{{end}}</head>
+<body>
+<p><a href="{{.Dest}}">{{.Title}}</a></p>
+</body>
+</html>
+`))
+
+// renderUnfurl devolve o HTML com Open Graph/Twitter card do anúncio. Não grava clique.
+func renderUnfurl(w http.ResponseWriter, t tenant.Tenant, portal, short string, e shortEntry) {
+	title := strings.TrimSpace(strings.SplitN(e.Description, "\n", 2)[0])
+	if title == "" {
+		title = t.Portal
+		if u, err := url.Parse(e.URL); err == nil && u.Host != "" { title = u.Hostname() }
+	}
+	img := ""
+	if e.Image != "" {
+		img = strings.TrimRight(t.Static, "/") + "/" + e.Image
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// os crawlers guardam o card por conta própria; do nosso lado um cache curto basta
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Vary", "User-Agent")
+	err := unfurlTmpl.Execute(w, unfurlData{
+		SiteName: t.Portal, Title: title, Description: e.Description,
+		Image: img, URL: canonicalShortURL(portal, short), Dest: e.URL,
+	})
+	if err != nil {
+		slog.Error("short unfurl: erro no template", "tenant", t.ID, "short", short, "err", err)
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"context"
+
+	mysqldb "ads-go/internal/storage/mysql"
+)
+
+// Store é de onde as rotas públicas leem catálogo e shortlinks e para onde vão os cliques:
+// MySQL (primário + réplicas) em produção, memória no modo dev (NewMemoryStore).
+type Store interface {
+	Up() bool // false = fora do ar: as rotas servem do último estado bom
+	catalog(ctx context.Context, tenantID int) ([]nodeItem, error)
+	short(ctx context.Context, tenantID int, code string) (e shortEntry, ok bool, err error)
+	saveClick(ctx context.Context, c clickLog) error
+}
+
+type mysqlStore struct{ db *mysqldb.DB }
+
+// NewMySQLStore lê nas réplicas e grava no primário.
+func NewMySQLStore(db *mysqldb.DB) Store { return mysqlStore{db: db} }
+
+func (s mysqlStore) Up() bool { return s.db.Up() }
+
+func (s mysqlStore) catalog(ctx context.Context, tenantID int) ([]nodeItem, error) {
+	return fetchActiveItems(ctx, s.db, tenantID)
+}
+
+func (s mysqlStore) short(ctx context.Context, tenantID int, code string) (shortEntry, bool, error) {
+	e, _, ok, err := fetchShortFromMySQL(ctx, s.db, tenantID, code, false)
+	// réplica atrasada ainda não tem o shortlink recém-criado no admin: confirma a falta no primário
+	// antes do 404 (e do negative cache de 4 minutos)
+	if _, replicas := s.db.Replicas(); err == nil && !ok && replicas > 0 {
+		e, _, ok, err = fetchShortFromMySQL(ctx, s.db, tenantID, code, true)
+	}
+	return e, ok, err
+}
+
+func (s mysqlStore) saveClick(ctx context.Context, c clickLog) error { return salvarClick(ctx, s.db, c) }
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ads-go/internal/fixture"
+)
+
+// memoryStore serve o modo dev a partir da fixture; cliques só vão para o log.
+type memoryStore struct {
+	ads    []fixture.Ad
+	clicks atomic.Int64
+}
+
+// NewMemoryStore monta o Store do modo dev (sem MySQL). As janelas started_at/validate_at
+// valem na hora da requisição, como no MySQL.
+func NewMemoryStore(ads []fixture.Ad) Store { return &memoryStore{ads: ads} }
+
+func (s *memoryStore) Up() bool { return true }
+
+func (s *memoryStore) catalog(_ context.Context, tenantID int) ([]nodeItem, error) {
+	now := time.Now()
+	out := make([]nodeItem, 0, len(s.ads))
+	for i := len(s.ads) - 1; i >= 0; i-- { // ORDER BY id DESC: o último da fixture vem primeiro
+		a := s.ads[i]
+		if a.Tenant != tenantID || !a.Active(now) || len(a.Types) == 0 { continue }
+		typed := make(map[int]nodeTypeVariant, len(a.Types))
+		for k, v := range a.Types {
+			tp, err := strconv.Atoi(k)
+			if err != nil { continue }
+			typed[tp] = nodeTypeVariant{File: strings.TrimSpace(v.File), Extension: strings.TrimSpace(v.Extension)}
+		}
+		out = append(out, nodeItem{Code: a.Code, Description: a.Description, Breackpoint: a.Breackpoint, Types: typed})
+	}
+	lastCatalogRead.Store(now.UnixNano())
+	return out, nil
+}
+
+func (s *memoryStore) short(_ context.Context, tenantID int, code string) (shortEntry, bool, error) {
+	for _, a := range s.ads {
+		if a.Tenant != tenantID || a.Deleted || !strings.EqualFold(a.Code, code) { continue }
+		e := shortEntry{URL: a.Redirect, UUID: a.UUID, Description: a.Description}
+		// mesmas regras de leitura da linha do MySQL (colunas JSON types/variants)
+		if b, err := json.Marshal(a.Types); err == nil && len(a.Types) > 0 { e.Image = shortImage(string(b)) }
+		if b, err := json.Marshal(a.Variants); err == nil && len(a.Variants) > 0 { e.Variants = parseVariants(string(b)) }
+		return e, true, nil
+	}
+	return shortEntry{}, false, nil
+}
+
+func (s *memoryStore) saveClick(ctx context.Context, c clickLog) error {
+	slog.InfoContext(ctx, "dev: clique", "uuid", c.UUID, "variant", c.Variant, "source", c.Source, "total", s.clicks.Add(1))
+	return nil
+}
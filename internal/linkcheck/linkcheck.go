@@ -0,0 +1,301 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Target é um destino a verificar (shortlink, variante A/B ou destino de anúncio).
+type Target struct {
+	TenantID int    `json:"tenant_id"`
+	Code     string `json:"code"`
+	URL      string `json:"url"`
+}
+
+// Hop é um passo de redirect seguido durante a verificação.
+type Hop struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+// Result é o último estado conhecido de um destino.
+type Result struct {
+	Target
+	Status       int           `json:"status"` // 0 = sem resposta (erro de rede/timeout)
+	Err          string        `json:"error,omitempty"`
+	Latency      time.Duration `json:"latency_ns"`
+	FinalURL     string        `json:"final_url"`
+	History      []Hop         `json:"history,omitempty"`
+	CheckedAt    time.Time     `json:"checked_at"`
+	Failures     int           `json:"failures"`                // falhas consecutivas
+	FailingSince time.Time     `json:"failing_since,omitempty"` // zero se OK
+}
+
+func (r Result) OK() bool { return r.Err == "" && r.Status > 0 && r.Status < 400 }
+
+// Source lista os destinos ativos (ex.: MySQL).
+type Source interface {
+	Targets(ctx context.Context) ([]Target, error)
+}
+
+type Options struct {
+	Interval     time.Duration // intervalo entre varreduras
+	Concurrency  int           // verificações simultâneas
+	HostInterval time.Duration // espaçamento mínimo entre requests ao mesmo host
+	MaxRedirects int
+	Timeout      time.Duration // por verificação (inclui redirects)
+	// Validate, se definido, barra destinos antes de qualquer request e a cada redirect (ex.: safeurl).
+	Validate func(tenantID int, url string) error
+
+	allowPrivate bool // testes: o httptest escuta em 127.0.0.1
+}
+
+// Checker varre os destinos periodicamente e guarda o último resultado em memória.
+type Checker struct {
+	src  Source
+	opt  Options
+	lim  *hostLimiter
+	tr   http.RoundTripper
+	mu   sync.RWMutex
+	res  map[string]Result
+	last time.Time
+}
+
+func New(src Source, opt Options) *Checker {
+	if opt.Concurrency <= 0 { opt.Concurrency = 8 }
+	if opt.MaxRedirects <= 0 { opt.MaxRedirects = 5 }
+	if opt.Timeout <= 0 { opt.Timeout = 10 * time.Second }
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !opt.allowPrivate { dialer.Control = publicOnly }
+	return &Checker{
+		src: src, opt: opt,
+		lim: &hostLimiter{every: opt.HostInterval, slot: map[string]time.Time{}},
+		// sem proxy do ambiente: o Control precisa ver o IP do destino, não o do proxy
+		tr:  &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second, MaxIdleConnsPerHost: 2, IdleConnTimeout: time.Minute},
+		res: map[string]Result{},
+	}
+}
+
+// cgnat: 100.64.0.0/10 (IP interno de provedor/nuvem, fora do IsPrivate)
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicOnly recusa conexões para loopback, redes privadas, link-local (169.254.169.254 = metadata
+// da nuvem) e afins: um destino que redireciona para dentro não vira SSRF. Roda depois do DNS,
+// então vale também para nomes que resolvem para IP interno.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil { return err }
+	ip, err := netip.ParseAddr(host)
+	if err != nil { return err }
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || ip.IsInterfaceLocalMulticast() || cgnat.Contains(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddr, ip)
+	}
+	return nil
+}
+
+func key(t Target) string { return fmt.Sprintf("%d|%s|%s", t.TenantID, t.Code, t.URL) }
+
+// Start roda uma varredura agora e depois a cada Interval, até ctx acabar.
+func (c *Checker) Start(ctx context.Context, logger *slog.Logger) {
+	go func() {
+		t := time.NewTicker(c.opt.Interval)
+		defer t.Stop()
+		for {
+			if err := c.RunOnce(ctx); err != nil && logger != nil {
+				logger.Error("linkcheck: varredura falhou", "err", err)
+			} else if logger != nil {
+				logger.Info("linkcheck: varredura ok", "targets", c.count(), "failing", len(c.Failing(0)))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// RunOnce faz uma varredura completa com no máximo Concurrency checagens simultâneas.
+func (c *Checker) RunOnce(ctx context.Context) error {
+	targets, err := c.src.Targets(ctx)
+	if err != nil { return err }
+
+	sem := make(chan struct{}, c.opt.Concurrency)
+	var wg sync.WaitGroup
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		k := key(t)
+		if seen[k] { continue }
+		seen[k] = true
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.record(c.check(ctx, t))
+		}(t)
+	}
+	wg.Wait()
+
+	// esquece destinos que saíram do ar (deletados/desativados)
+	c.mu.Lock()
+	for k := range c.res {
+		if !seen[k] { delete(c.res, k) }
+	}
+	c.last = time.Now()
+	c.mu.Unlock()
+	c.lim.prune()
+	return nil
+}
+
+func (c *Checker) check(ctx context.Context, t Target) Result {
+	r := Result{Target: t, CheckedAt: time.Now()}
+	if c.opt.Validate != nil {
+		if err := c.opt.Validate(t.TenantID, t.URL); err != nil {
+			r.Err = err.Error()
+			return r
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, t.URL, nil)
+	if err != nil {
+		r.Err = err.Error()
+		return r
+	}
+	req.Header.Set("User-Agent", "ads-go-linkcheck/1.0")
+
+	// espera a vez do host antes de começar a contar o timeout
+	if err := c.lim.wait(ctx, req.URL.Host); err != nil {
+		r.Err = err.Error()
+		return r
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.opt.Timeout)
+	defer cancel()
+	client := &http.Client{
+		Transport: c.tr,
+		// cada salto passa pelas mesmas regras do destino original: validação e vez do host
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.Response != nil {
+				r.History = append(r.History, Hop{URL: req.Response.Request.URL.String(), Status: req.Response.StatusCode})
+			}
+			if len(via) > c.opt.MaxRedirects { return errTooManyRedirects }
+			if c.opt.Validate != nil {
+				if err := c.opt.Validate(t.TenantID, req.URL.String()); err != nil { return fmt.Errorf("redirect para %s: %w", req.URL, err) }
+			}
+			return c.lim.wait(req.Context(), req.URL.Host)
+		},
+	}
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Err = err.Error()
+		return r
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	r.Status = resp.StatusCode
+	r.FinalURL = resp.Request.URL.String()
+	return r
+}
+
+var (
+	errTooManyRedirects = errors.New("redirects demais")
+	errPrivateAddr      = errors.New("endereço interno não permitido")
+)
+
+func (c *Checker) record(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev, had := c.res[key(r.Target)]
+	if !r.OK() {
+		r.Failures = 1
+		r.FailingSince = r.CheckedAt
+		if had && !prev.OK() {
+			r.Failures = prev.Failures + 1
+			r.FailingSince = prev.FailingSince
+		}
+	}
+	c.res[key(r.Target)] = r
+}
+
+func (c *Checker) count() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.res)
+}
+
+// Failing lista os destinos falhando há pelo menos minAge (mais antigos primeiro).
+func (c *Checker) Failing(minAge time.Duration) []Result {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := time.Now()
+	out := make([]Result, 0)
+	for _, r := range c.res {
+		if !r.OK() && now.Sub(r.FailingSince) >= minAge { out = append(out, r) }
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FailingSince.Before(out[j].FailingSince) })
+	return out
+}
+
+// LastRun é o fim da última varredura completa (zero se nunca rodou).
+func (c *Checker) LastRun() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.last
+}
+
+// hostLimiter espaça as verificações ao mesmo host.
+type hostLimiter struct {
+	every time.Duration
+	mu    sync.Mutex
+	slot  map[string]time.Time
+}
+
+// prune descarta os slots que já passaram, para o mapa não crescer com hosts que saíram da lista.
+func (h *hostLimiter) prune() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for host, at := range h.slot {
+		if !at.After(now) { delete(h.slot, host) }
+	}
+}
+
+func (h *hostLimiter) wait(ctx context.Context, host string) error {
+	if h.every <= 0 { return nil }
+	h.mu.Lock()
+	now := time.Now()
+	at := h.slot[host]
+	if at.Before(now) { at = now }
+	h.slot[host] = at.Add(h.every)
+	h.mu.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 { return nil }
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
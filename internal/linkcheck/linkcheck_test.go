@@ -0,0 +1,158 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type staticSource []Target
+
+func (s staticSource) Targets(context.Context) ([]Target, error) { return s, nil }
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux.HandleFunc("/404", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	mux.HandleFunc("/500", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/b", http.StatusFound) })
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/ok", http.StatusMovedPermanently) })
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/loop", http.StatusFound) })
+	mux.HandleFunc("/to-blocked", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/blocked", http.StatusFound) })
+	mux.HandleFunc("/blocked", func(w http.ResponseWriter, r *http.Request) { t.Error("salto bloqueado foi requisitado") })
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheck(t *testing.T) {
+	srv := testServer(t)
+	validate := func(_ int, url string) error {
+		if strings.Contains(url, "/blocked") { return errors.New("bloqueado") }
+		return nil
+	}
+	tests := []struct {
+		name       string
+		path       string
+		ok         bool
+		status     int
+		err        string
+		minLatency time.Duration
+		history    []int
+		final      string
+	}{
+		{name: "200", path: "/ok", ok: true, status: 200, final: "/ok"},
+		{name: "404", path: "/404", status: 404, final: "/404"},
+		{name: "500", path: "/500", status: 500, final: "/500"},
+		{name: "latência", path: "/slow", ok: true, status: 200, minLatency: 50 * time.Millisecond, final: "/slow"},
+		{name: "histórico de redirects", path: "/a", ok: true, status: 200, history: []int{302, 301}, final: "/ok"},
+		{name: "redirects demais", path: "/loop", err: "redirects demais", history: []int{302, 302, 302}},
+		{name: "salto barrado pelo Validate", path: "/to-blocked", err: "bloqueado", history: []int{302}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(nil, Options{MaxRedirects: 2, Timeout: 2 * time.Second, Validate: validate, allowPrivate: true})
+			r := c.check(context.Background(), Target{TenantID: 1, Code: "x", URL: srv.URL + tt.path})
+			if r.OK() != tt.ok { t.Fatalf("OK() = %v, quer %v (status %d, err %q)", r.OK(), tt.ok, r.Status, r.Err) }
+			if r.Status != tt.status { t.Errorf("status = %d, quer %d", r.Status, tt.status) }
+			if !strings.Contains(r.Err, tt.err) || (tt.err == "") != (r.Err == "") { t.Errorf("err = %q, quer %q", r.Err, tt.err) }
+			if r.Latency < tt.minLatency { t.Errorf("latência = %v, quer >= %v", r.Latency, tt.minLatency) }
+			if tt.final != "" && r.FinalURL != srv.URL+tt.final { t.Errorf("final = %q, quer %q", r.FinalURL, srv.URL+tt.final) }
+			if len(r.History) != len(tt.history) { t.Fatalf("history = %+v, quer status %v", r.History, tt.history) }
+			for i, h := range r.History {
+				if h.Status != tt.history[i] { t.Errorf("history[%d].status = %d, quer %d", i, h.Status, tt.history[i]) }
+			}
+		})
+	}
+}
+
+func TestCheckRecusaEnderecoInterno(t *testing.T) {
+	srv := testServer(t)
+	c := New(nil, Options{Timeout: 2 * time.Second})
+	r := c.check(context.Background(), Target{URL: srv.URL + "/ok"})
+	if r.OK() || !strings.Contains(r.Err, errPrivateAddr.Error()) { t.Fatalf("quer recusa de loopback, veio status %d err %q", r.Status, r.Err) }
+
+	for _, addr := range []string{"169.254.169.254:80", "10.0.0.1:80", "192.168.0.1:443", "[::1]:80", "100.64.1.1:80", "[::ffff:127.0.0.1]:80"} {
+		if err := publicOnly("tcp", addr, nil); !errors.Is(err, errPrivateAddr) { t.Errorf("%s: err = %v, quer errPrivateAddr", addr, err) }
+	}
+	if err := publicOnly("tcp", "93.184.216.34:443", nil); err != nil { t.Errorf("IP público recusado: %v", err) }
+}
+
+func TestHostInterval(t *testing.T) {
+	var (
+		mu sync.Mutex
+		at []time.Time
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		at = append(at, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	const every = 80 * time.Millisecond
+	src := staticSource{{Code: "a", URL: srv.URL + "/a"}, {Code: "b", URL: srv.URL + "/b"}, {Code: "c", URL: srv.URL + "/c"}}
+	c := New(src, Options{Concurrency: 3, HostInterval: every, allowPrivate: true})
+	if err := c.RunOnce(context.Background()); err != nil { t.Fatal(err) }
+	if len(at) != 3 { t.Fatalf("requests = %d, quer 3", len(at)) }
+	for i := 1; i < len(at); i++ {
+		// margem para o agendamento das goroutines
+		if gap := at[i].Sub(at[i-1]); gap < every-10*time.Millisecond { t.Errorf("intervalo %d = %v, quer >= %v", i, gap, every) }
+	}
+
+	// passado o último slot, a varredura seguinte começa com o mapa limpo
+	time.Sleep(every)
+	c.src = staticSource{}
+	if err := c.RunOnce(context.Background()); err != nil { t.Fatal(err) }
+	c.lim.mu.Lock()
+	n := len(c.lim.slot)
+	c.lim.mu.Unlock()
+	if n != 0 { t.Errorf("slots depois da varredura = %d, quer 0", n) }
+}
+
+func TestFailAfter(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		status = http.StatusBadGateway
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	src := staticSource{{TenantID: 1, Code: "x", URL: srv.URL}}
+	c := New(src, Options{allowPrivate: true})
+	ctx := context.Background()
+	for range 2 {
+		if err := c.RunOnce(ctx); err != nil { t.Fatal(err) }
+	}
+	failing := c.Failing(0)
+	if len(failing) != 1 { t.Fatalf("failing = %d, quer 1", len(failing)) }
+	if f := failing[0]; f.Failures != 2 || !f.FailingSince.Before(f.CheckedAt) {
+		t.Errorf("failures = %d, failing_since = %v (checked_at %v): quer 2 e desde a primeira falha", f.Failures, f.FailingSince, f.CheckedAt)
+	}
+	// LINKCHECK_FAIL_AFTER: só entra no relatório depois de falhar por minAge
+	if n := len(c.Failing(time.Hour)); n != 0 { t.Errorf("Failing(1h) = %d, quer 0", n) }
+
+	mu.Lock()
+	status = http.StatusOK
+	mu.Unlock()
+	if err := c.RunOnce(ctx); err != nil { t.Fatal(err) }
+	if n := len(c.Failing(0)); n != 0 { t.Errorf("depois de voltar: failing = %d, quer 0", n) }
+
+	c.src = staticSource{}
+	if err := c.RunOnce(ctx); err != nil { t.Fatal(err) }
+	if n := c.count(); n != 0 { t.Errorf("destino removido continua no resultado (%d)", n) }
+}
@@ -0,0 +1,66 @@
+package linkcheck
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// Querier é o que a fonte precisa do banco (*sql.DB ou o *mysql.DB com réplicas).
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type mysqlSource struct {
+	db      Querier
+	tenants func() []int
+}
+
+// NewMySQLSource lista destinos (redirect + variantes A/B) dos anúncios/shortlinks não deletados de
+// cada tenant. Mesmo filtro do /{short}: status e a janela started_at/validate_at valem para o
+// anúncio, mas o shortlink continua redirecionando fora deles.
+func NewMySQLSource(db Querier, tenants func() []int) Source {
+	return &mysqlSource{db: db, tenants: tenants}
+}
+
+func (s *mysqlSource) Targets(ctx context.Context) ([]Target, error) {
+	const q = `
+		SELECT code, COALESCE(redirect,''), variants
+		FROM ads
+		WHERE tenant_id = ?
+		  AND deleted_at IS NULL
+	`
+	out := make([]Target, 0, 256)
+	for _, tid := range s.tenants() {
+		rows, err := s.db.QueryContext(ctx, q, tid)
+		if err != nil { return nil, err }
+		for rows.Next() {
+			var (
+				code, redirect string
+				variantsJSON   sql.NullString
+			)
+			if err := rows.Scan(&code, &redirect, &variantsJSON); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if u := strings.TrimSpace(redirect); u != "" {
+				out = append(out, Target{TenantID: tid, Code: code, URL: u})
+			}
+			if variantsJSON.Valid && strings.TrimSpace(variantsJSON.String) != "" {
+				var vs []struct{ URL string `json:"url"` }
+				if json.Unmarshal([]byte(variantsJSON.String), &vs) == nil {
+					for _, v := range vs {
+						if u := strings.TrimSpace(v.URL); u != "" {
+							out = append(out, Target{TenantID: tid, Code: code, URL: u})
+						}
+					}
+				}
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil { return nil, err }
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Rótulos com cardinalidade limitada: rota é o padrão do chi ("/{short}", nunca o código),
+// tenant é o ID do registro, tier/result/outcome são enums fixos.
+var (
+	registry = prometheus.NewRegistry()
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ads_http_request_duration_seconds",
+		Help:    "Latência das requisições HTTP por rota (padrão do chi), método e status.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"route", "method", "status"})
+
+	AdsServed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ads_served_total",
+		Help: "Anúncios entregues por tenant e endpoint (root, amp).",
+	}, []string{"tenant", "endpoint"})
+
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ads_shortlink_requests_total",
+		Help: "Acessos a shortlink por tenant e desfecho (redirect, preview, unfurl, blocked, not_found).",
+	}, []string{"tenant", "outcome"})
+
+	Cache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ads_cache_requests_total",
+		Help: "Consultas de cache por camada (redis, negative, snapshot) e resultado (hit, miss).",
+	}, []string{"tier", "result"})
+
+	MySQLErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ads_mysql_errors_total",
+		Help: "Erros de MySQL por operação.",
+	}, []string{"op"})
+
+	EventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ads_events_dropped_total",
+		Help: "Eventos de clique descartados, por motivo.",
+	}, []string{"reason"})
+
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ads_ratelimited_total",
+		Help: "Requisições recusadas com 429, por grupo de rotas e escopo (ip, tenant).",
+	}, []string{"group", "scope"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPDuration, AdsServed, Redirects, Cache, MySQLErrors, EventsDropped, RateLimited,
+	)
+}
+
+// Tenant formata o rótulo de tenant.
+func Tenant(id int) string { return strconv.Itoa(id) }
+
+// CacheResult conta um hit/miss na camada tier.
+func CacheResult(tier string, hit bool) {
+	if hit {
+		Cache.WithLabelValues(tier, "hit").Inc()
+		return
+	}
+	Cache.WithLabelValues(tier, "miss").Inc()
+}
+
+// RegisterDB expõe as estatísticas de um pool (db_name = primary, replica-0, ...).
+func RegisterDB(name string, db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterAge expõe um gauge com a idade (s) de um timestamp, ex.: última leitura do catálogo.
+// Zero (nunca aconteceu) vira -1.
+func RegisterAge(name, help string, at func() time.Time) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 {
+		t := at()
+		if t.IsZero() { return -1 }
+		return time.Since(t).Seconds()
+	}))
+}
+
+// RegisterQueueDepth expõe o tamanho da fila de cliques em disco.
+func RegisterQueueDepth(depth func() int) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "ads_event_queue_depth",
+		Help: "Eventos de clique na fila em disco aguardando o MySQL.",
+	}, func() float64 { return float64(depth()) }))
+}
+
+// Handler serve /metrics no formato texto do Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
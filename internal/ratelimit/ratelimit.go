@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit: Rate requisições a cada Per, com rajada de até Burst.
+type Limit struct {
+	Rate  int
+	Per   time.Duration
+	Burst int
+}
+
+func (l Limit) interval() time.Duration { return l.Per / time.Duration(l.Rate) }
+
+// depois de uma falha do Redis, fica no limite local por esse tempo (não paga o timeout a cada requisição)
+const redisBackoff = 5 * time.Second
+
+// Limiter aplica GCRA no Redis (orçamento compartilhado entre as instâncias) e cai para o
+// mesmo algoritmo em memória, por instância, quando o Redis não está configurado ou falha.
+type Limiter struct {
+	rdb       redis.UniversalClient // nil = só local
+	local     *localStore
+	skipUntil atomic.Int64 // unix nano; Redis ignorado até lá
+}
+
+func New(rdb redis.UniversalClient) *Limiter {
+	return &Limiter{rdb: rdb, local: newLocalStore()}
+}
+
+// GCRA (generic cell rate algorithm): guarda só o "theoretical arrival time" da chave.
+// ARGV: burst, intervalo de emissão (s). Devolve {1, 0} ou {0, retry_after_ms}.
+var gcra = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then tat = now end
+local new_tat = tat + interval
+local allow_at = new_tat - interval * burst
+if allow_at > now then
+  return {0, math.ceil((allow_at - now) * 1000)}
+end
+redis.call("SET", KEYS[1], tostring(new_tat), "PX", math.ceil((new_tat - now) * 1000))
+return {1, 0}
+`)
+
+// Allow consome uma requisição de key; se negado, retryAfter diz quando tentar de novo.
+func (l *Limiter) Allow(ctx context.Context, key string, lim Limit) (ok bool, retryAfter time.Duration) {
+	if lim.Rate <= 0 || lim.Per <= 0 { return true, 0 }
+	if lim.Burst < 1 { lim.Burst = 1 }
+	if l.rdb != nil && time.Now().UnixNano() >= l.skipUntil.Load() {
+		res, err := gcra.Run(ctx, l.rdb, []string{key}, lim.Burst, lim.interval().Seconds()).Int64Slice()
+		if err == nil && len(res) == 2 {
+			return res[0] == 1, time.Duration(res[1]) * time.Millisecond
+		}
+		if ctx.Err() != nil { return true, 0 } // cliente desistiu: não é falha do Redis
+		l.skipUntil.Store(time.Now().Add(redisBackoff).UnixNano())
+		slog.WarnContext(ctx, "ratelimit: redis indisponível, usando limite local", "err", err, "retry_in", redisBackoff.String())
+	}
+	return l.local.allow(key, lim, time.Now())
+}
+
+// localStore: o mesmo GCRA em memória (token bucket equivalente, por instância).
+type localStore struct {
+	mu    sync.Mutex
+	tat   map[string]time.Time
+	sweep time.Time
+}
+
+func newLocalStore() *localStore { return &localStore{tat: map[string]time.Time{}} }
+
+func (s *localStore) allow(key string, lim Limit, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.sweep) > time.Minute { s.sweepLocked(now) }
+
+	interval := lim.interval()
+	tat := s.tat[key]
+	if tat.Before(now) { tat = now }
+	newTAT := tat.Add(interval)
+	if allowAt := newTAT.Add(-interval * time.Duration(lim.Burst)); allowAt.After(now) {
+		return false, allowAt.Sub(now)
+	}
+	s.tat[key] = newTAT
+	return true, 0
+}
+
+// sweepLocked descarta chaves cujo TAT já passou (equivalem a uma chave nova).
+func (s *localStore) sweepLocked(now time.Time) {
+	for k, t := range s.tat {
+		if t.Before(now) { delete(s.tat, k) }
+	}
+	s.sweep = now
+}
+
+// RetryAfter formata o header Retry-After (segundos inteiros, mínimo 1).
+func RetryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGCRALocal(t *testing.T) {
+	lim := Limit{Rate: 60, Per: time.Minute, Burst: 3} // uma por segundo, rajada de 3
+	t0 := time.Unix(1_700_000_000, 0)
+	type step struct {
+		at    time.Duration // desde t0
+		key   string
+		ok    bool
+		retry time.Duration
+	}
+	steps := []step{
+		{0, "a", true, 0},
+		{0, "a", true, 0},
+		{0, "a", true, 0},
+		{0, "a", false, time.Second}, // rajada esgotada: a próxima célula libera em 1s
+		{0, "b", true, 0},            // chaves independentes
+		{500 * time.Millisecond, "a", false, 500 * time.Millisecond},
+		{time.Second, "a", true, 0}, // uma célula reposta
+		{time.Second, "a", false, time.Second},
+		{10 * time.Second, "a", true, 0}, // parado por muito tempo: volta a rajada inteira, não mais
+		{10 * time.Second, "a", true, 0},
+		{10 * time.Second, "a", true, 0},
+		{10 * time.Second, "a", false, time.Second},
+	}
+	s := newLocalStore()
+	for i, st := range steps {
+		ok, retry := s.allow(st.key, lim, t0.Add(st.at))
+		if ok != st.ok || retry != st.retry { t.Errorf("passo %d (%s em +%v): ok=%v retry=%v, quer ok=%v retry=%v", i, st.key, st.at, ok, retry, st.ok, st.retry) }
+	}
+}
+
+func TestGCRASemRajada(t *testing.T) {
+	// Burst 0 vira 1: sem rajada, uma requisição por intervalo
+	l := New(nil)
+	lim := Limit{Rate: 1, Per: time.Hour}
+	if ok, _ := l.Allow(context.Background(), "k", lim); !ok { t.Fatal("primeira negada") }
+	ok, retry := l.Allow(context.Background(), "k", lim)
+	if ok || retry < 59*time.Minute { t.Fatalf("segunda: ok=%v retry=%v, quer negada por ~1h", ok, retry) }
+}
+
+func TestLimiteDesligado(t *testing.T) {
+	l := New(nil)
+	for _, lim := range []Limit{{}, {Rate: 0, Per: time.Second}, {Rate: 5, Per: 0}} {
+		for range 100 {
+			if ok, _ := l.Allow(context.Background(), "k", lim); !ok { t.Fatalf("%+v: negada com limite desligado", lim) }
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	s := newLocalStore()
+	lim := Limit{Rate: 1, Per: time.Second, Burst: 1}
+	t0 := time.Unix(1_700_000_000, 0)
+	s.allow("velha", lim, t0)
+	s.allow("nova", lim, t0.Add(2*time.Minute)) // passa do minuto: varre antes de gravar
+	if _, ok := s.tat["velha"]; ok { t.Error("chave com TAT vencido não foi descartada") }
+	if _, ok := s.tat["nova"]; !ok { t.Error("chave atual sumiu") }
+}
+
+func TestRedisForaCaiParaLocal(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer rdb.Close()
+	l := New(rdb)
+	lim := Limit{Rate: 1, Per: time.Minute, Burst: 1}
+	if ok, _ := l.Allow(context.Background(), "k", lim); !ok { t.Fatal("primeira negada") }
+	if l.skipUntil.Load() <= time.Now().UnixNano() { t.Fatal("falha do Redis não ligou o backoff") }
+	// no backoff nem tenta o Redis: o limite local já conta a primeira
+	if ok, retry := l.Allow(context.Background(), "k", lim); ok || retry <= 0 { t.Fatalf("segunda: ok=%v retry=%v, quer negada pelo limite local", ok, retry) }
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "1"},
+		{100 * time.Millisecond, "1"},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{time.Minute, "60"},
+	}
+	for _, tt := range tests {
+		if got := RetryAfter(tt.d); got != tt.want { t.Errorf("RetryAfter(%v) = %q, quer %q", tt.d, got, tt.want) }
+	}
+}
@@ -0,0 +1,127 @@
+package safeurl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Erros de validação (use errors.Is para distinguir).
+var (
+	ErrInvalid    = errors.New("url inválida")
+	ErrScheme     = errors.New("esquema não permitido")
+	ErrBlocked    = errors.New("domínio bloqueado")
+	ErrNotAllowed = errors.New("domínio fora da allowlist do tenant")
+)
+
+// só http/https; javascript:, data:, file: etc. caem aqui
+var allowedSchemes = map[string]bool{"http": true, "https": true}
+
+// Checker valida destinos de redirect (shortlinks e cliques).
+// Blocklist é global; allowlist é opcional por tenant (tenant sem allowlist aceita qualquer domínio não bloqueado).
+// As regras podem ser trocadas em runtime (Reload) sem lock no caminho do redirect.
+type Checker struct {
+	rules atomic.Pointer[rules]
+}
+
+type rules struct {
+	block []string
+	allow map[int][]string
+}
+
+// New monta o checker. blocklistFile pode ser vazio (sem blocklist).
+// Em erro de leitura o checker volta utilizável, só sem blocklist.
+func New(blocklistFile string, allow map[int][]string) (*Checker, error) {
+	c := &Checker{}
+	c.rules.Store(&rules{allow: normAllow(allow)})
+	return c, c.Reload(blocklistFile, allow)
+}
+
+// Reload troca blocklist/allowlist. Em erro as regras atuais são mantidas.
+func (c *Checker) Reload(blocklistFile string, allow map[int][]string) error {
+	r := &rules{allow: normAllow(allow)}
+	if blocklistFile != "" {
+		block, err := loadDomains(blocklistFile)
+		if err != nil { return err }
+		r.block = block
+	}
+	c.rules.Store(r)
+	return nil
+}
+
+func normAllow(allow map[int][]string) map[int][]string {
+	out := map[int][]string{}
+	for tid, doms := range allow {
+		for _, d := range doms {
+			if d = normDomain(d); d != "" { out[tid] = append(out[tid], d) }
+		}
+	}
+	return out
+}
+
+// loadDomains lê um domínio por linha; linhas vazias e comentários (#) são ignorados.
+func loadDomains(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil { return nil, err }
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 { line = line[:i] }
+		if d := normDomain(line); d != "" { out = append(out, d) }
+	}
+	return out, sc.Err()
+}
+
+func normDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	d = strings.TrimPrefix(d, "*.")
+	return strings.TrimSuffix(d, ".")
+}
+
+// matchDomain: host igual ao domínio ou subdomínio dele.
+func matchDomain(host string, list []string) bool {
+	for _, d := range list {
+		if host == d || strings.HasSuffix(host, "."+d) { return true }
+	}
+	return false
+}
+
+// Check valida raw para o tenant. Nil checker aceita só pelo esquema/forma.
+func (c *Checker) Check(tenantID int, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, " \t\r\n\\") {
+		return ErrInvalid
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return fmt.Errorf("%w: %q", ErrScheme, u.Scheme)
+	}
+	// "https://portal.com.br@evil.com" engana quem lê o link
+	if u.User != nil || u.Host == "" {
+		return ErrInvalid
+	}
+	host := normDomain(u.Hostname())
+	if host == "" {
+		return ErrInvalid
+	}
+	if c == nil {
+		return nil
+	}
+	r := c.rules.Load()
+	if matchDomain(host, r.block) {
+		return fmt.Errorf("%w: %s", ErrBlocked, host)
+	}
+	if allow, ok := r.allow[tenantID]; ok && len(allow) > 0 && !matchDomain(host, allow) {
+		return fmt.Errorf("%w: %s", ErrNotAllowed, host)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrFull: a fila chegou ao limite de linhas; o evento é descartado.
+var ErrFull = errors.New("spool cheio")
+
+// Spool é uma fila em disco (uma linha JSON por evento) para gravar depois,
+// quando o destino (MySQL) voltar. Sobrevive a restart.
+type Spool struct {
+	path string
+	max  int
+
+	drain sync.Mutex // uma drenagem por vez
+	mu    sync.Mutex // arquivo e contador
+	n     int        // linhas no arquivo
+	out   *os.File
+}
+
+// Open abre (ou cria) o arquivo da fila; max <= 0 = sem limite. Uma linha sem '\n' no fim (crash
+// no meio de um Append, nunca confirmado) é cortada: senão o próximo Append colaria nela.
+func Open(path string, max int) (*Spool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { return nil, err }
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil { return nil, err }
+	s := &Spool{path: path, max: max, out: f}
+	good, err := eachLine(f, -1, func([]byte) error { s.n++; return nil })
+	if err == nil {
+		var st os.FileInfo
+		if st, err = f.Stat(); err == nil && st.Size() > good {
+			slog.Warn("spool: linha incompleta descartada", "path", path, "bytes", st.Size()-good)
+			if err = f.Truncate(good); err == nil { err = f.Sync() }
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// eachLine lê r em streaming até limit bytes (< 0 = até o fim) e chama fn para cada linha completa
+// não vazia. Devolve os bytes consumidos (até o fim da última linha entregue); para no primeiro erro
+// de fn, sem contar a linha que falhou.
+func eachLine(r io.Reader, limit int64, fn func(line []byte) error) (int64, error) {
+	if limit >= 0 { r = io.LimitReader(r, limit) }
+	br := bufio.NewReaderSize(r, 64*1024)
+	var consumed int64
+	for {
+		b, err := br.ReadBytes('\n')
+		if err == io.EOF { return consumed, nil } // o que sobrou sem '\n' é linha incompleta
+		if err != nil { return consumed, err }
+		if line := bytes.TrimSpace(b); len(line) > 0 {
+			if err := fn(line); err != nil { return consumed, err }
+		}
+		consumed += int64(len(b))
+	}
+}
+
+// Append enfileira v (fsync a cada evento: clique aceito não se perde em crash).
+func (s *Spool) Append(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil { return err }
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.max > 0 && s.n >= s.max { return ErrFull }
+	if _, err := s.out.Write(append(b, '\n')); err != nil { return err }
+	s.n++
+	return s.out.Sync()
+}
+
+// Len é o número de eventos aguardando.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
+// Drain entrega os eventos em ordem a fn; para no primeiro erro e mantém dali em diante.
+// Append continua livre enquanto fn roda (o lock só é pego para medir o arquivo e para reescrever).
+// A fila é lida em streaming, nunca inteira na memória. Devolve quantos foram entregues.
+func (s *Spool) Drain(ctx context.Context, fn func(ctx context.Context, line json.RawMessage) error) (int, error) {
+	s.drain.Lock()
+	defer s.drain.Unlock()
+
+	in, err := os.Open(s.path)
+	if err != nil { return 0, err }
+	defer in.Close()
+	// só até o tamanho de agora: Append grava linhas inteiras sob s.mu, então é fim de linha
+	s.mu.Lock()
+	st, err := s.out.Stat()
+	s.mu.Unlock()
+	if err != nil { return 0, err }
+
+	done := 0
+	consumed, ferr := eachLine(in, st.Size(), func(line []byte) error {
+		if err := ctx.Err(); err != nil { return err }
+		if err := fn(ctx, line); err != nil { return err }
+		done++
+		return nil
+	})
+	if consumed == 0 { return 0, ferr }
+
+	// reescreve o resto (inclui o que foi enfileirado durante a drenagem) num arquivo novo, que já
+	// aberto em append vira o s.out depois do rename: não há reabertura que possa falhar
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil { return done, err }
+	_, err = in.Seek(consumed, io.SeekStart)
+	if err == nil { _, err = io.Copy(out, in) }
+	if err == nil { err = out.Sync() }
+	if err == nil { err = os.Rename(tmp, s.path) }
+	if err != nil {
+		// o arquivo original segue intacto (e com s.out aberto): as linhas entregues saem de novo na próxima
+		out.Close()
+		os.Remove(tmp)
+		return done, err
+	}
+	s.out.Close()
+	s.out = out
+	s.n -= done
+	return done, ferr
+}
+
+// Start tenta esvaziar a fila a cada interval (só quando ready(), ex.: MySQL de pé) até ctx acabar.
+func (s *Spool) Start(ctx context.Context, interval time.Duration, ready func() bool, fn func(ctx context.Context, line json.RawMessage) error) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			if s.Len() == 0 || !ready() { continue }
+			n, err := s.Drain(ctx, fn)
+			if n > 0 { slog.Info("spool: eventos gravados", "path", s.path, "count", n, "pending", s.Len()) }
+			if err != nil { slog.Warn("spool: drenagem interrompida", "path", s.path, "pending", s.Len(), "err", err) }
+		}
+	}()
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.out.Close()
+}
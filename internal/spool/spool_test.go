@@ -0,0 +1,84 @@
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func collect(got *[]string) func(context.Context, json.RawMessage) error {
+	return func(_ context.Context, line json.RawMessage) error {
+		*got = append(*got, string(line))
+		return nil
+	}
+}
+
+func TestDrain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q.jsonl")
+	s, err := Open(path, 0)
+	if err != nil { t.Fatal(err) }
+	defer s.Close()
+	for i := range 4 {
+		if err := s.Append(map[string]int{"i": i}); err != nil { t.Fatal(err) }
+	}
+
+	// para no primeiro erro; o que falhou e o resto ficam na fila
+	var got []string
+	boom := errors.New("boom")
+	n, err := s.Drain(context.Background(), func(ctx context.Context, line json.RawMessage) error {
+		if len(got) == 2 { return boom }
+		return collect(&got)(ctx, line)
+	})
+	if n != 2 || !errors.Is(err, boom) { t.Fatalf("drain = %d, %v; quer 2, boom", n, err) }
+	if s.Len() != 2 { t.Fatalf("Len = %d, quer 2", s.Len()) }
+
+	// append depois da reescrita vai para o arquivo novo
+	if err := s.Append(map[string]int{"i": 4}); err != nil { t.Fatal(err) }
+	got = nil
+	if n, err := s.Drain(context.Background(), collect(&got)); n != 3 || err != nil { t.Fatalf("drain = %d, %v; quer 3, nil", n, err) }
+	want := []string{`{"i":2}`, `{"i":3}`, `{"i":4}`}
+	if strings.Join(got, " ") != strings.Join(want, " ") { t.Fatalf("entregues %v, quer %v", got, want) }
+	if s.Len() != 0 { t.Errorf("Len = %d, quer 0", s.Len()) }
+	if b, _ := os.ReadFile(path); len(b) != 0 { t.Errorf("arquivo depois de esvaziar: %q", b) }
+}
+
+func TestOpenCortaLinhaIncompleta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q.jsonl")
+	if err := os.WriteFile(path, []byte("{\"i\":0}\n\n{\"i\":1}\n{\"i\":"), 0o644); err != nil { t.Fatal(err) }
+	s, err := Open(path, 0)
+	if err != nil { t.Fatal(err) }
+	defer s.Close()
+	if s.Len() != 2 { t.Fatalf("Len = %d, quer 2 (linha vazia e incompleta não contam)", s.Len()) }
+
+	if err := s.Append(map[string]int{"i": 2}); err != nil { t.Fatal(err) }
+	var got []string
+	if n, err := s.Drain(context.Background(), collect(&got)); n != 3 || err != nil { t.Fatalf("drain = %d, %v; quer 3, nil", n, err) }
+	if got[2] != `{"i":2}` { t.Errorf("append depois do corte = %q, quer {\"i\":2}", got[2]) }
+}
+
+func TestLinhaLonga(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q.jsonl")
+	s, err := Open(path, 0)
+	if err != nil { t.Fatal(err) }
+	big := strings.Repeat("x", 2<<20) // maior que o buffer de leitura
+	if err := s.Append(big); err != nil { t.Fatal(err) }
+	s.Close()
+
+	if s, err = Open(path, 0); err != nil { t.Fatal(err) }
+	defer s.Close()
+	if s.Len() != 1 { t.Fatalf("Len = %d, quer 1", s.Len()) }
+	var got []string
+	if n, err := s.Drain(context.Background(), collect(&got)); n != 1 || err != nil || len(got[0]) != len(big)+2 { t.Fatalf("drain = %d, %v", n, err) }
+}
+
+func TestFull(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "q.jsonl"), 1)
+	if err != nil { t.Fatal(err) }
+	defer s.Close()
+	if err := s.Append(1); err != nil { t.Fatal(err) }
+	if err := s.Append(2); !errors.Is(err, ErrFull) { t.Fatalf("err = %v, quer ErrFull", err) }
+}
@@ -0,0 +1,233 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrações versionadas, embutidas no binário: sql/NNNN_nome.up.sql e sql/NNNN_nome.down.sql.
+// DDL no MySQL não é transacional: cada arquivo deve ser pequeno o bastante para, se falhar
+// no meio, dar para consertar à mão (a versão só é registrada depois de tudo aplicado).
+// Um down só com comentários marca a migração como irreversível (Down para nela).
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+const (
+	table   = "schema_migrations"
+	lockKey = "ads-go:migrate" // GET_LOCK: uma instância migrando por vez
+)
+
+// lockWait: segundos de cada tentativa de GET_LOCK enquanto outra instância migra; entre uma e
+// outra só registra que continua esperando (var: o teste de integração encurta)
+var lockWait = 30
+
+var fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration é um par up/down de uma versão.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State é uma migração com a data em que foi aplicada (zero = pendente).
+// Unknown: aplicada no banco, mas não existe neste binário (veio de uma versão mais nova).
+type State struct {
+	Migration
+	AppliedAt time.Time
+	Unknown   bool
+}
+
+// ErrLocked: o ctx acabou esperando o lock de outra instância que está migrando.
+var ErrLocked = errors.New("migrate: outra instância está migrando")
+
+// ErrIrreversible: o down da migração é só um comentário (ex.: 0001/0002, que adotam tabelas
+// de produção criadas antes das migrações: desfazer seria apagar os dados).
+var ErrIrreversible = errors.New("migrate: migração irreversível")
+
+// All lê as migrações embutidas, em ordem de versão.
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil { return nil, err }
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := fileRe.FindStringSubmatch(e.Name())
+		if m == nil { return nil, fmt.Errorf("migrate: nome inválido %q (esperado NNNN_nome.up|down.sql)", e.Name()) }
+		v, _ := strconv.Atoi(m[1])
+		b, err := files.ReadFile("sql/" + e.Name())
+		if err != nil { return nil, err }
+		mg := byVersion[v]
+		if mg == nil {
+			mg = &Migration{Version: v, Name: m[2]}
+			byVersion[v] = mg
+		}
+		if mg.Name != m[2] { return nil, fmt.Errorf("migrate: versão %d com nomes diferentes (%s, %s)", v, mg.Name, m[2]) }
+		if m[3] == "up" { mg.Up = string(b) } else { mg.Down = string(b) }
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" || mg.Down == "" { return nil, fmt.Errorf("migrate: versão %d sem up ou down", mg.Version) }
+		out = append(out, *mg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up aplica as pendentes em ordem; devolve as versões aplicadas.
+func Up(ctx context.Context, db *sql.DB) ([]int, error) {
+	var done []int
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		all, applied, err := load(ctx, conn)
+		if err != nil { return err }
+		for _, m := range all {
+			if _, ok := applied[m.Version]; ok { continue }
+			if err := exec(ctx, conn, m.Up); err != nil { return fmt.Errorf("migrate: %04d_%s up: %w", m.Version, m.Name, err) }
+			if _, err := conn.ExecContext(ctx, "INSERT INTO "+table+" (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now()); err != nil {
+				return err
+			}
+			slog.Info("migrate: aplicada", "version", m.Version, "name", m.Name)
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down desfaz as últimas steps migrações aplicadas (da mais nova para a mais antiga).
+func Down(ctx context.Context, db *sql.DB, steps int) ([]int, error) {
+	var done []int
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		all, applied, err := load(ctx, conn)
+		if err != nil { return err }
+		for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+			m := all[i]
+			if _, ok := applied[m.Version]; !ok { continue }
+			if len(split(m.Down)) == 0 { return fmt.Errorf("migrate: %04d_%s down: %w", m.Version, m.Name, ErrIrreversible) }
+			if err := exec(ctx, conn, m.Down); err != nil { return fmt.Errorf("migrate: %04d_%s down: %w", m.Version, m.Name, err) }
+			if _, err := conn.ExecContext(ctx, "DELETE FROM "+table+" WHERE version = ?", m.Version); err != nil { return err }
+			slog.Info("migrate: desfeita", "version", m.Version, "name", m.Name)
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lista todas as migrações (do binário e do banco) com o estado de cada uma.
+func Status(ctx context.Context, db *sql.DB) ([]State, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil { return nil, err }
+	defer conn.Close()
+	all, applied, err := load(ctx, conn)
+	if err != nil { return nil, err }
+	out := make([]State, 0, len(all))
+	for _, m := range all {
+		out = append(out, State{Migration: m, AppliedAt: applied[m.Version].at})
+		delete(applied, m.Version)
+	}
+	for v, a := range applied {
+		out = append(out, State{Migration: Migration{Version: v, Name: a.name}, AppliedAt: a.at, Unknown: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Pending conta as migrações ainda não aplicadas.
+func Pending(ctx context.Context, db *sql.DB) (int, error) {
+	st, err := Status(ctx, db)
+	if err != nil { return 0, err }
+	n := 0
+	for _, s := range st {
+		if s.AppliedAt.IsZero() { n++ }
+	}
+	return n, nil
+}
+
+type appliedRow struct {
+	name string
+	at   time.Time
+}
+
+// load cria a tabela de versões se preciso e devolve as migrações do binário e as aplicadas.
+func load(ctx context.Context, conn *sql.Conn) ([]Migration, map[int]appliedRow, error) {
+	all, err := All()
+	if err != nil { return nil, nil, err }
+	const ddl = `CREATE TABLE IF NOT EXISTS ` + table + ` (
+		version    INT UNSIGNED NOT NULL PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		applied_at DATETIME     NOT NULL
+	) ENGINE=InnoDB`
+	if _, err := conn.ExecContext(ctx, ddl); err != nil { return nil, nil, err }
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM "+table)
+	if err != nil { return nil, nil, err }
+	defer rows.Close()
+	applied := map[int]appliedRow{}
+	for rows.Next() {
+		var (
+			v int
+			a appliedRow
+		)
+		if err := rows.Scan(&v, &a.name, &a.at); err != nil { return nil, nil, err }
+		applied[v] = a
+	}
+	return all, applied, rows.Err()
+}
+
+// withLock roda fn numa conexão dedicada segurando o GET_LOCK (o lock é da conexão:
+// se o processo morrer, o MySQL o libera sozinho). Com outra instância migrando, espera ela
+// terminar (fn então acha tudo aplicado) até o ctx acabar.
+func withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil { return err }
+	defer conn.Close()
+	for waited := 0; ; waited += lockWait {
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockKey, lockWait).Scan(&got); err != nil {
+			if ctx.Err() != nil { return fmt.Errorf("%w: %v", ErrLocked, ctx.Err()) }
+			return err
+		}
+		if got.Int64 == 1 { break }
+		if ctx.Err() != nil { return fmt.Errorf("%w: %v", ErrLocked, ctx.Err()) }
+		slog.Info("migrate: outra instância está migrando; aguardando o lock", "waited_s", waited+lockWait)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockKey)
+	return fn(conn)
+}
+
+// exec roda um arquivo com várias instruções, uma por vez (o DSN não precisa de multiStatements).
+func exec(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range split(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil { return err }
+	}
+	return nil
+}
+
+// split separa as instruções por ";" no fim da linha; linhas só de comentário são ignoradas.
+func split(script string) []string {
+	var out []string
+	var cur strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		t := strings.TrimSpace(line)
+		if cur.Len() == 0 && (t == "" || strings.HasPrefix(t, "--")) { continue }
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+		if strings.HasSuffix(t, ";") {
+			out = append(out, strings.TrimSuffix(strings.TrimSpace(cur.String()), ";"))
+			cur.Reset()
+		}
+	}
+	if s := strings.TrimSpace(cur.String()); s != "" { out = append(out, s) }
+	return out
+}
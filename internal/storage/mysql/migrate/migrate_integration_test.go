@@ -0,0 +1,139 @@
+//go:build integration
+
+// Roda contra um MySQL de teste (o banco é zerado!):
+//
+//	MIGRATE_TEST_DSN='root:root@tcp(127.0.0.1:3306)/ads_test?parseTime=true' go test -tags integration ./internal/storage/mysql/migrate/
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("MIGRATE_TEST_DSN")
+	if dsn == "" { t.Skip("MIGRATE_TEST_DSN não definido") }
+	db, err := sql.Open("mysql", dsn)
+	if err != nil { t.Fatal(err) }
+	t.Cleanup(func() { db.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil { t.Fatal(err) }
+
+	// começa do zero: desfaz o que der (0001/0002 são irreversíveis) e apaga as tabelas e o controle de versões
+	if _, err := Down(ctx, db, 1<<20); err != nil && !errors.Is(err, ErrIrreversible) { t.Fatal(err) }
+	for _, tb := range []string{"ads_logs", "ads", "tenants", table} {
+		if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+tb); err != nil { t.Fatal(err) }
+	}
+	return db
+}
+
+func versions(ms []Migration) []int {
+	out := make([]int, 0, len(ms))
+	for _, m := range ms { out = append(out, m.Version) }
+	return out
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) { return false }
+	for i := range a {
+		if a[i] != b[i] { return false }
+	}
+	return true
+}
+
+func TestUpStatusDownUp(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	all, err := All()
+	if err != nil { t.Fatal(err) }
+	want := versions(all)
+
+	done, err := Up(ctx, db)
+	if err != nil { t.Fatalf("up: %v (aplicadas %v)", err, done) }
+	if !equal(done, want) { t.Fatalf("up aplicou %v, quer %v", done, want) }
+
+	st, err := Status(ctx, db)
+	if err != nil { t.Fatal(err) }
+	for _, s := range st {
+		if s.AppliedAt.IsZero() || s.Unknown { t.Errorf("status %04d_%s: applied_at %v, unknown %v", s.Version, s.Name, s.AppliedAt, s.Unknown) }
+	}
+	// colunas que os ALTER (0004+) acrescentam
+	for _, q := range []string{
+		"SELECT variants, live_code FROM ads LIMIT 0",
+		"SELECT variant, source FROM ads_logs LIMIT 0",
+		"SELECT aliases, timezone, cors_origins FROM tenants LIMIT 0",
+	} {
+		if _, err := db.ExecContext(ctx, q); err != nil { t.Errorf("%s: %v", q, err) }
+	}
+
+	if done, err = Up(ctx, db); err != nil || len(done) != 0 { t.Fatalf("segundo up: aplicadas %v, err %v; quer nada", done, err) }
+
+	// down desfaz da mais nova para a mais antiga e para na 0002, que adota a tabela de produção
+	done, err = Down(ctx, db, len(all))
+	if !errors.Is(err, ErrIrreversible) { t.Fatalf("down até o fim: err %v, quer ErrIrreversible", err) }
+	reversible := want[2:]
+	if len(done) != len(reversible) || done[0] != want[len(want)-1] { t.Fatalf("down desfez %v, quer %v da mais nova para a mais antiga", done, reversible) }
+	if n, err := Pending(ctx, db); err != nil || n != len(reversible) { t.Fatalf("pendentes depois do down = %d (err %v), quer %d", n, err, len(reversible)) }
+	if _, err := db.ExecContext(ctx, "SELECT id FROM ads LIMIT 0"); err != nil { t.Errorf("down irreversível apagou ads: %v", err) }
+
+	if done, err = Up(ctx, db); err != nil || !equal(done, reversible) { t.Fatalf("up depois do down: %v, err %v; quer %v", done, err, reversible) }
+}
+
+func TestUpConcorrente(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	all, err := All()
+	if err != nil { t.Fatal(err) }
+
+	// duas instâncias subindo juntas: uma migra, a outra espera o lock e não acha nada pendente
+	old := lockWait
+	lockWait = 1
+	defer func() { lockWait = old }()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied []int
+	)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := Up(ctx, db)
+			if err != nil { t.Errorf("up concorrente: %v", err) }
+			mu.Lock()
+			applied = append(applied, done...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Ints(applied)
+	if !equal(applied, versions(all)) { t.Fatalf("aplicadas %v, quer cada versão uma vez: %v", applied, versions(all)) }
+
+	// lock preso por outra conexão: Up continua esperando (várias rodadas de lockWait) e segue
+	// quando ele é solto; só desiste com ErrLocked quando o ctx acaba
+	conn, err := db.Conn(ctx)
+	if err != nil { t.Fatal(err) }
+	defer conn.Close()
+	var got int
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", lockKey).Scan(&got); err != nil || got != 1 { t.Fatalf("GET_LOCK: %d, %v", got, err) }
+
+	short, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancel()
+	if _, err := Up(short, db); !errors.Is(err, ErrLocked) { t.Fatalf("up com o lock preso até o fim do ctx: err %v, quer ErrLocked", err) }
+
+	release := time.AfterFunc(2500*time.Millisecond, func() { conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockKey) })
+	defer release.Stop()
+	start := time.Now()
+	if done, err := Up(ctx, db); err != nil || len(done) != 0 { t.Fatalf("up esperando o lock: aplicadas %v, err %v; quer nada e sem erro", done, err) }
+	if time.Since(start) < 2*time.Second { t.Errorf("up não esperou o lock ser solto (%v)", time.Since(start)) }
+}
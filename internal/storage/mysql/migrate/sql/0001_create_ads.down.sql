@@ -0,0 +1,2 @@
+-- Irreversível: 0001 adota a tabela ads que já existia em produção antes das migrações;
+-- desfazer seria apagar anúncios e shortlinks. "migrate down" para aqui com ErrIrreversible.
@@ -0,0 +1,24 @@
+-- Anúncios e shortlinks (uma linha serve aos dois: code é o shortlink, types são os criativos).
+-- "breackpoint" é o nome histórico da coluna (o front e as consultas usam assim).
+-- IF NOT EXISTS (0001-0003): bancos criados à mão antes das migrações só registram a versão.
+-- Por isso 0001-0003 têm o formato desses bancos; as colunas novas vêm depois, com ALTER (0004+).
+CREATE TABLE IF NOT EXISTS ads (
+	id          INT UNSIGNED     NOT NULL AUTO_INCREMENT,
+	tenant_id   INT UNSIGNED     NOT NULL,
+	code        VARCHAR(64)      NOT NULL,
+	uuid        CHAR(36)         NOT NULL,
+	description VARCHAR(255)     NULL,
+	redirect    VARCHAR(2048)    NULL,
+	status      TINYINT          NOT NULL DEFAULT 1,  -- 1 = ativo
+	breackpoint INT              NOT NULL DEFAULT 0,
+	types       JSON             NULL,                -- {"3":{"file":"...","extension":"png"}}
+	started_at  DATETIME         NULL,
+	validate_at DATETIME         NULL,                -- fim da veiculação
+	deleted_at  DATETIME         NULL,                -- soft delete
+	created_at  DATETIME         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at  DATETIME         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	UNIQUE KEY ads_uuid (uuid),
+	KEY ads_tenant_code (tenant_id, code),
+	KEY ads_tenant_active (tenant_id, status, deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+-- Irreversível: 0002 adota a tabela ads_logs que já existia em produção antes das migrações;
+-- desfazer seria apagar o histórico de cliques. "migrate down" para aqui com ErrIrreversible.
@@ -0,0 +1,13 @@
+-- Cliques nos shortlinks (um por redirect; prévia e crawlers não contam).
+CREATE TABLE IF NOT EXISTS ads_logs (
+	id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	uuid       CHAR(36)        NOT NULL,  -- ads.uuid
+	tenant_id  INT UNSIGNED    NOT NULL,
+	ip         VARCHAR(45)     NOT NULL,
+	user_agent VARCHAR(512)    NOT NULL DEFAULT '',
+	referer    VARCHAR(2048)   NOT NULL DEFAULT '',
+	created_at DATETIME        NOT NULL,  -- hora do clique (a fila em disco grava depois)
+	PRIMARY KEY (id),
+	KEY ads_logs_uuid_created (uuid, created_at),
+	KEY ads_logs_tenant_created (tenant_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+-- Registro de tenants (TENANTS_SOURCE=mysql).
+CREATE TABLE IF NOT EXISTS tenants (
+	id           INT UNSIGNED  NOT NULL,
+	portal       VARCHAR(255)  NOT NULL,  -- host principal, ex.: portal.com.br
+	ads_url      VARCHAR(2048) NOT NULL DEFAULT '',
+	static       VARCHAR(2048) NOT NULL DEFAULT '',
+	active       TINYINT       NOT NULL DEFAULT 1,
+	PRIMARY KEY (id),
+	UNIQUE KEY tenants_portal (portal)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+ALTER TABLE ads
+	DROP INDEX ads_tenant_live_code,
+	DROP COLUMN live_code,
+	DROP COLUMN variants;
@@ -0,0 +1,7 @@
+-- Destinos A/B dos shortlinks e código único por tenant (o /admin responde 409 no duplicado).
+-- Falha no UNIQUE = já há códigos repetidos entre os não removidos do tenant: resolva à mão e rode de novo.
+ALTER TABLE ads
+	ADD COLUMN variants JSON NULL AFTER types,  -- [{"key":"a","url":"https://...","weight":50}]
+	-- code é único entre os não removidos do tenant (removido libera o código)
+	ADD COLUMN live_code VARCHAR(64) AS (IF(deleted_at IS NULL, code, NULL)) STORED,
+	ADD UNIQUE KEY ads_tenant_live_code (tenant_id, live_code);
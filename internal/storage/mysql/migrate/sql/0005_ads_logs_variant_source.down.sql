@@ -0,0 +1,3 @@
+ALTER TABLE ads_logs
+	DROP COLUMN source,
+	DROP COLUMN variant;
@@ -0,0 +1,4 @@
+-- Variante A/B e origem (?src=, ex.: qr) de cada clique.
+ALTER TABLE ads_logs
+	ADD COLUMN variant VARCHAR(64) NULL AFTER referer,
+	ADD COLUMN source  VARCHAR(32) NULL AFTER variant;
@@ -0,0 +1,4 @@
+ALTER TABLE tenants
+	DROP COLUMN cors_origins,
+	DROP COLUMN timezone,
+	DROP COLUMN aliases;
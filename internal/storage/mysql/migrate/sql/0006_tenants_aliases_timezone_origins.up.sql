@@ -0,0 +1,6 @@
+-- Hosts alternativos, fuso e origens CORS por tenant (TENANTS_SOURCE=mysql).
+-- cors_origins: ["https://*.portal.com.br"]; vazio = portal + aliases
+ALTER TABLE tenants
+	ADD COLUMN aliases      JSON        NULL AFTER portal,  -- ["www.portal.com.br"]
+	ADD COLUMN timezone     VARCHAR(64) NULL AFTER static,  -- IANA, ex.: America/Sao_Paulo
+	ADD COLUMN cors_origins JSON        NULL AFTER timezone;
@@ -1,20 +1,235 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
-	"log"
-	"os"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/trace"
+
+	"ads-go/internal/telemetry"
 )
 
-// Open abre conexão usando a env MYSQL_DSN (ex.: user:pass@tcp(127.0.0.1:3306)/db?parseTime=true)
-func Open() (*sql.DB, error) {
-	dsn := os.Getenv("MYSQL_DSN")
-	if dsn == "" {
-		log.Println("MYSQL_DSN vazio — defina a string de conexão")
+// Options configura o primário, as réplicas e os pools.
+type Options struct {
+	DSN             string   // primário (ex.: user:pass@tcp(127.0.0.1:3306)/db?parseTime=true)
+	ReplicaDSNs     []string // réplicas de leitura (opcional)
+	MaxOpen         int
+	MaxIdle         int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	ReadTimeout     time.Duration // por consulta (0 = sem limite além do contexto)
+	WriteTimeout    time.Duration
+}
+
+// DB separa leitura/escrita: escritas sempre no primário; leituras nas réplicas saudáveis
+// (round-robin), caindo para o primário quando nenhuma responde.
+type DB struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+	opt      Options
+	up       atomic.Bool // último Ping do primário respondeu
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// Open abre o primário e as réplicas (sql.Open não conecta; use Ping para checar).
+func Open(opt Options) (*DB, error) {
+	if opt.DSN == "" {
+		return nil, errors.New("MYSQL_DSN vazio — defina a string de conexão")
+	}
+	primary, err := open(opt.DSN, opt)
+	if err != nil { return nil, err }
+	d := &DB{primary: primary, opt: opt}
+	d.up.Store(true) // otimista até o primeiro Ping
+	for _, dsn := range opt.ReplicaDSNs {
+		rdb, err := open(dsn, opt)
+		if err != nil {
+			_ = d.Close()
+			return nil, err
+		}
+		rp := &replica{db: rdb}
+		rp.healthy.Store(true) // otimista até o primeiro health check
+		d.replicas = append(d.replicas, rp)
+	}
+	return d, nil
+}
+
+func open(dsn string, opt Options) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil { return nil, err }
+	db.SetMaxOpenConns(opt.MaxOpen)
+	db.SetMaxIdleConns(opt.MaxIdle)
+	db.SetConnMaxLifetime(opt.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opt.ConnMaxIdleTime)
+	return db, nil
+}
+
+// Primary é o pool de escrita (INSERT de cliques, migrações, admin).
+func (d *DB) Primary() *sql.DB { return d.primary }
+
+// Reader escolhe uma réplica saudável ou o primário.
+func (d *DB) Reader() *sql.DB {
+	_, db := d.reader()
+	return db
+}
+
+func (d *DB) reader() (string, *sql.DB) {
+	n := len(d.replicas)
+	if n == 0 { return "primary", d.primary }
+	start := int(d.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		if j := (start + i) % n; d.replicas[j].healthy.Load() { return "replica-" + strconv.Itoa(j), d.replicas[j].db }
 	}
-	return sql.Open("mysql", dsn)
+	return "primary", d.primary
 }
 
-func Ping(db *sql.DB) error { return db.Ping() }
+var tracer = telemetry.Tracer("mysql")
+
+// QueryContext / QueryRowContext vão para o Reader; ExecContext para o primário. Cada chamada vira um span.
+// Nas leituras o span cobre só a ida e volta até o primeiro resultado: termina no return, antes de
+// quem chama iterar/escanear as linhas (*sql.Rows é concreto, não dá para fechar o span no Close).
+func (d *DB) QueryContext(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	pool, db := d.reader()
+	ctx, span := tracer.Start(ctx, "mysql.query", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(telemetry.DBAttrs("mysql", pool, q)...))
+	defer span.End()
+	rows, err := db.QueryContext(ctx, q, args...)
+	telemetry.SpanError(span, err)
+	return rows, err
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, q string, args ...any) *sql.Row {
+	pool, db := d.reader()
+	return queryRow(ctx, pool, db, q, args)
+}
+
+// QueryRowPrimaryContext lê no primário: para quando o atraso das réplicas não serve (confirmar
+// que uma linha não existe antes de cachear a falta, por exemplo).
+func (d *DB) QueryRowPrimaryContext(ctx context.Context, q string, args ...any) *sql.Row {
+	return queryRow(ctx, "primary", d.primary, q, args)
+}
+
+func queryRow(ctx context.Context, pool string, db *sql.DB, q string, args []any) *sql.Row {
+	ctx, span := tracer.Start(ctx, "mysql.query", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(telemetry.DBAttrs("mysql", pool, q)...))
+	defer span.End()
+	row := db.QueryRowContext(ctx, q, args...)
+	if err := row.Err(); err != sql.ErrNoRows { telemetry.SpanError(span, err) }
+	return row
+}
+
+func (d *DB) ExecContext(ctx context.Context, q string, args ...any) (sql.Result, error) {
+	ctx, span := tracer.Start(ctx, "mysql.exec", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(telemetry.DBAttrs("mysql", "primary", q)...))
+	defer span.End()
+	res, err := d.primary.ExecContext(ctx, q, args...)
+	telemetry.SpanError(span, err)
+	return res, err
+}
+
+// ReadContext/WriteContext aplicam o timeout por consulta configurado.
+func (d *DB) ReadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, d.opt.ReadTimeout)
+}
+
+func (d *DB) WriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, d.opt.WriteTimeout)
+}
+
+func withTimeout(ctx context.Context, t time.Duration) (context.Context, context.CancelFunc) {
+	if t <= 0 { return context.WithCancel(ctx) }
+	return context.WithTimeout(ctx, t)
+}
+
+// Ping checa o primário (réplicas fora do ar não impedem o start) e atualiza Up.
+func (d *DB) Ping(ctx context.Context) error {
+	err := d.primary.PingContext(ctx)
+	if was := d.up.Swap(err == nil); was && err != nil {
+		slog.Error("mysql: primário fora do ar", "err", err)
+	} else if !was && err == nil {
+		slog.Info("mysql: primário de volta")
+	}
+	return err
+}
+
+// Up diz se o primário respondeu ao último Ping. Fora do ar, quem chama pode pular direto
+// para o fallback (snapshot, fila em disco) em vez de esperar o timeout de cada consulta.
+func (d *DB) Up() bool { return d != nil && d.up.Load() }
+
+// StartHealthCheck pinga o primário e as réplicas a cada interval: réplicas entram/saem do
+// rodízio e o primário fora do ar é re-tentado até voltar.
+func (d *DB) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 { return }
+	check := func() {
+		pctx, cancel := context.WithTimeout(ctx, interval)
+		_ = d.Ping(pctx)
+		cancel()
+		for i, rp := range d.replicas {
+			pctx, cancel := context.WithTimeout(ctx, interval)
+			err := rp.db.PingContext(pctx)
+			cancel()
+			was := rp.healthy.Swap(err == nil)
+			if was && err != nil {
+				slog.Warn("mysql: réplica fora do rodízio", "replica", i, "err", err)
+			} else if !was && err == nil {
+				slog.Info("mysql: réplica de volta ao rodízio", "replica", i)
+			}
+		}
+	}
+	go func() {
+		check()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				check()
+			}
+		}
+	}()
+}
+
+// Replicas devolve quantas réplicas estão no rodízio e quantas existem.
+func (d *DB) Replicas() (healthy, total int) {
+	for _, rp := range d.replicas {
+		if rp.healthy.Load() { healthy++ }
+	}
+	return healthy, len(d.replicas)
+}
+
+// EachPool chama fn para o primário e cada réplica (nomes iguais aos de Stats).
+func (d *DB) EachPool(fn func(name string, db *sql.DB)) {
+	fn("primary", d.primary)
+	for i, rp := range d.replicas { fn("replica-"+strconv.Itoa(i), rp.db) }
+}
+
+// PoolStats é o retrato de um pool (primário ou réplica).
+type PoolStats struct {
+	Name    string      `json:"name"`
+	Healthy bool        `json:"healthy"`
+	Stats   sql.DBStats `json:"stats"`
+}
+
+// Stats devolve as estatísticas de todos os pools.
+func (d *DB) Stats() []PoolStats {
+	out := []PoolStats{{Name: "primary", Healthy: true, Stats: d.primary.Stats()}}
+	for i, rp := range d.replicas {
+		out = append(out, PoolStats{Name: "replica-" + strconv.Itoa(i), Healthy: rp.healthy.Load(), Stats: rp.db.Stats()})
+	}
+	return out
+}
+
+func (d *DB) Close() error {
+	err := d.primary.Close()
+	for _, rp := range d.replicas { _ = rp.db.Close() }
+	return err
+}
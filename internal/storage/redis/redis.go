@@ -1,32 +1,80 @@
 package redis
 
 import (
-	"strconv"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 	"strings"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"ads-go/internal/config"
 )
 
-// New retorna um client Redis OU nil se REDIS_URL vazio.
-func New(cfg config.Config) (*redis.Client, error) {
+// New retorna um client Redis (single, Sentinel ou Cluster, conforme REDIS_MODE) OU nil se
+// nem REDIS_URL nem REDIS_ADDRS (ou o antigo REDIS_ADDR) estiverem definidos. Quem usa depende de redis.UniversalClient.
+func New(cfg config.Config) (redis.UniversalClient, error) {
 	url := strings.TrimSpace(cfg.RedisURL)
-	if url == "" { return nil, nil }
-	if strings.HasPrefix(url, "redis://") || strings.HasPrefix(url, "rediss://") {
-		opt, err := redis.ParseURL(url)
+	if url == "" && len(cfg.RedisAddrs) == 0 { return nil, nil }
+
+	opt := &redis.UniversalOptions{
+		Addrs:            cfg.RedisAddrs,
+		Username:         cfg.RedisUsername,
+		Password:         cfg.RedisPassword,
+		DB:               cfg.RedisDB,
+		MasterName:       cfg.RedisMasterName,
+		SentinelPassword: cfg.RedisSentinelPassword,
+		IsClusterMode:    cfg.RedisMode == config.RedisCluster,
+		PoolSize:         cfg.RedisPoolSize,
+		MinIdleConns:     cfg.RedisMinIdle,
+		DialTimeout:      cfg.RedisDialTimeout,
+		ReadTimeout:      cfg.RedisReadTimeout,
+		WriteTimeout:     cfg.RedisWriteTimeout,
+	}
+
+	// REDIS_URL (redis:// ou rediss://) tem prioridade sobre endereço/credenciais/DB avulsos
+	if url != "" {
+		u, err := redis.ParseURL(url)
 		if err != nil { return nil, err }
-		return redis.NewClient(opt), nil
+		opt.Addrs = []string{u.Addr}
+		if u.Username != "" { opt.Username = u.Username }
+		if u.Password != "" { opt.Password = u.Password }
+		opt.DB = u.DB
+		opt.TLSConfig = u.TLSConfig
 	}
-	addr := getenv("REDIS_ADDR", "127.0.0.1:6379")
-	pass := getenv("REDIS_PASSWORD", "")
-	db, _ := strconv.Atoi(getenv("REDIS_DB", "0"))
-	return redis.NewClient(&redis.Options{Addr: addr, Password: pass, DB: db}), nil
-}
 
-func getenv(k, def string) string {
-	if v := strings.TrimSpace(getenvRaw(k)); v != "" { return v }
-	return def
+	if cfg.RedisMode == config.RedisSentinel && opt.MasterName == "" {
+		return nil, errors.New("REDIS_MODE=sentinel exige REDIS_MASTER_NAME")
+	}
+	if cfg.RedisMode != config.RedisSentinel { opt.MasterName = "" }
+
+	if cfg.RedisTLS || opt.TLSConfig != nil {
+		tc, err := tlsConfig(cfg, opt.TLSConfig)
+		if err != nil { return nil, err }
+		opt.TLSConfig = tc
+	}
+	rdb := redis.NewUniversalClient(opt)
+	// um span por comando/pipeline (provider global; no-op com TRACE_EXPORTER=none)
+	if err := redisotel.InstrumentTracing(rdb, redisotel.WithDBStatement(false)); err != nil {
+		_ = rdb.Close()
+		return nil, err
+	}
+	return rdb, nil
 }
 
-// pode ser substituído por os.LookupEnv no main; mantido assim para testes
-var getenvRaw = func(key string) string { return "" }
+func tlsConfig(cfg config.Config, base *tls.Config) (*tls.Config, error) {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil { tc = base.Clone() }
+	if cfg.RedisTLSServerName != "" { tc.ServerName = cfg.RedisTLSServerName }
+	tc.InsecureSkipVerify = cfg.RedisTLSInsecure
+	if cfg.RedisTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.RedisTLSCAFile)
+		if err != nil { return nil, err }
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) { return nil, fmt.Errorf("REDIS_TLS_CA_FILE: nenhum certificado em %s", cfg.RedisTLSCAFile) }
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
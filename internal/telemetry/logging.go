@@ -1,12 +1,97 @@
 package telemetry
 
 import (
-	"log"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+
+	"ads-go/internal/tenant"
 )
 
-type Fields map[string]any
+// LogOptions configura o logger do serviço.
+type LogOptions struct {
+	Level     *slog.LevelVar // recarregável (SIGHUP)
+	Format    string         // json | text
+	RedactIPs bool           // mascara atributos "ip" (IPv4 /24, IPv6 /48)
+}
+
+// NewLogger monta o slog do serviço: JSON (ou texto em dev), segredos/IPs redigidos e,
+// em toda linha logada com o contexto da requisição, request_id, tenant, route, trace_id e elapsed_ms.
+func NewLogger(w io.Writer, opt LogOptions) *slog.Logger {
+	ho := &slog.HandlerOptions{
+		Level: opt.Level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if isSecretKey(a.Key) { return slog.String(a.Key, "***") }
+			if opt.RedactIPs && a.Key == "ip" { return slog.String(a.Key, RedactIP(a.Value.String())) }
+			return a
+		},
+	}
+	var h slog.Handler
+	if opt.Format == "text" {
+		h = slog.NewTextHandler(w, ho)
+	} else {
+		h = slog.NewJSONHandler(w, ho)
+	}
+	return slog.New(ctxHandler{h})
+}
+
+// ParseLevel aceita debug, info, warn e error.
+func ParseLevel(s string) (slog.Level, bool) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil { return slog.LevelInfo, false }
+	return l, true
+}
+
+// chaves cujo valor nunca vai para o log
+var secretKeys = []string{"api_key", "apikey", "secret", "password", "passwd", "token", "authorization", "dsn", "cookie"}
+
+func isSecretKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range secretKeys {
+		if strings.Contains(k, s) { return true }
+	}
+	return false
+}
+
+// RedactIP zera o fim do endereço: 203.0.113.57 -> 203.0.113.0, 2001:db8:1:2::1 -> 2001:db8:1::.
+// Aceita também "ip:porta" (RemoteAddr).
+func RedactIP(s string) string {
+	if h, _, err := net.SplitHostPort(s); err == nil { s = h }
+	ip := net.ParseIP(s)
+	if ip == nil { return "invalid" }
+	if v4 := ip.To4(); v4 != nil { return v4.Mask(net.CIDRMask(24, 32)).String() }
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+type startKey struct{}
+
+// WithStart marca o início da requisição (elapsed_ms nas linhas de log).
+func WithStart(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, startKey{}, t)
+}
+
+// ctxHandler acrescenta os campos da requisição tirados do contexto.
+type ctxHandler struct{ slog.Handler }
+
+func (h ctxHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := middleware.GetReqID(ctx); id != "" { r.AddAttrs(slog.String("request_id", id)) }
+		if t, ok := tenant.FromContext(ctx); ok { r.AddAttrs(slog.Int("tenant", t.ID)) }
+		if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" { r.AddAttrs(slog.String("route", rc.RoutePattern())) }
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+		if start, ok := ctx.Value(startKey{}).(time.Time); ok { r.AddAttrs(slog.Int64("elapsed_ms", time.Since(start).Milliseconds())) }
+	}
+	return h.Handler.Handle(ctx, r)
+}
 
-func Info(msg string, f Fields) { log.Printf("level=info msg=%q fields=%v", msg, f) }
-func Error(msg string, f Fields) { log.Printf("level=error msg=%q fields=%v", msg, f) }
-func Now() time.Time { return time.Now() }
+func (h ctxHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return ctxHandler{h.Handler.WithAttrs(attrs)} }
+func (h ctxHandler) WithGroup(name string) slog.Handler       { return ctxHandler{h.Handler.WithGroup(name)} }
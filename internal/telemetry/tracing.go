@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exportadores de trace aceitos em TRACE_EXPORTER.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout" // dev: spans em JSON no stdout
+	ExporterOTLP   = "otlp"   // OTLP/HTTP para o collector local
+)
+
+// TraceOptions configura o tracing.
+type TraceOptions struct {
+	Exporter     string
+	OTLPEndpoint string             // host:porta do collector (OTLP/HTTP, sem TLS)
+	Ratio        float64            // amostragem padrão (0..1)
+	Routes       map[string]float64 // amostragem por rota do chi, ex.: "/_health/ready" -> 0
+	Version      string
+}
+
+// Tracer dos pacotes do serviço.
+func Tracer(name string) trace.Tracer { return otel.Tracer("ads-go/" + name) }
+
+// SetupTracing instala o provider global e o propagador W3C (traceparent + baggage).
+// Com Exporter "none" os spans ficam no-op, mas o traceparent recebido continua sendo lido.
+// Devolve o shutdown (flush dos spans pendentes).
+func SetupTracing(ctx context.Context, opt TraceOptions) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch opt.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exp, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpoint(opt.OTLPEndpoint), otlptracehttp.WithInsecure())
+	default:
+		return nil, fmt.Errorf("exportador de trace desconhecido: %q", opt.Exporter)
+	}
+	if err != nil { return nil, err }
+
+	res := resource.NewSchemaless(semconv.ServiceName("ads-go"), semconv.ServiceVersion(opt.Version))
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(newRouteSampler(opt.Ratio, opt.Routes))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// routeSampler usa a taxa da rota (atributo http.route do span de entrada) ou a padrão.
+// Spans filhos seguem o pai (ParentBased), então a decisão vale para a requisição inteira.
+type routeSampler struct {
+	def    sdktrace.Sampler
+	routes map[string]sdktrace.Sampler
+}
+
+func newRouteSampler(ratio float64, routes map[string]float64) sdktrace.Sampler {
+	s := routeSampler{def: sdktrace.TraceIDRatioBased(ratio), routes: map[string]sdktrace.Sampler{}}
+	for route, r := range routes { s.routes[route] = sdktrace.TraceIDRatioBased(r) }
+	return s
+}
+
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, a := range p.Attributes {
+		if a.Key == semconv.HTTPRouteKey {
+			if rs, ok := s.routes[a.Value.AsString()]; ok { return rs.ShouldSample(p) }
+			break
+		}
+	}
+	return s.def.ShouldSample(p)
+}
+
+func (s routeSampler) Description() string {
+	parts := make([]string, 0, len(s.routes))
+	for route := range s.routes { parts = append(parts, route) }
+	return "RouteSampler{default=" + s.def.Description() + ",routes=" + strings.Join(parts, ",") + "}"
+}
+
+// SpanError marca o span com o erro (nil = nada a fazer).
+func SpanError(span trace.Span, err error) {
+	if err == nil { return }
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// DBAttrs são os atributos comuns dos spans de banco.
+func DBAttrs(system, pool, stmt string) []attribute.KeyValue {
+	if len(stmt) > 300 { stmt = stmt[:300] }
+	return []attribute.KeyValue{
+		semconv.DBSystemKey.String(system),
+		attribute.String("db.pool", pool),
+		semconv.DBQueryText(strings.Join(strings.Fields(stmt), " ")),
+	}
+}
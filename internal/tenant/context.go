@@ -0,0 +1,16 @@
+package tenant
+
+import "context"
+
+type ctxKey struct{}
+
+// WithContext guarda o tenant resolvido da requisição (ver middleware.Tenant).
+func WithContext(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, ctxKey{}, t)
+}
+
+// FromContext devolve o tenant guardado por WithContext; ok=false se não houver.
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(ctxKey{}).(Tenant)
+	return t, ok
+}
@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+)
+
+type fileSource struct{ path string }
+
+// NewFileSource lê um JSON com a lista de tenants:
+// [{"id":1,"portal":"...","aliases":["www...."],"ads_url":"...","static":"...","timezone":"America/Sao_Paulo",
+// "cors_origins":["https://*.portal.com.br"]}]
+func NewFileSource(path string) Source { return &fileSource{path: path} }
+
+type staticSource []Tenant
+
+// NewStaticSource devolve sempre a mesma lista (ex.: tenants do snapshot com o MySQL fora do ar).
+func NewStaticSource(list []Tenant) Source { return staticSource(list) }
+
+func (s staticSource) Load(_ context.Context) ([]Tenant, error) { return s, nil }
+
+func (s *fileSource) Load(_ context.Context) ([]Tenant, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil { return nil, err }
+	var raw []struct {
+		ID       int      `json:"id"`
+		Portal   string   `json:"portal"`
+		Aliases  []string `json:"aliases"`
+		AdsURL   string   `json:"ads_url"`
+		Static   string   `json:"static"`
+		Timezone string   `json:"timezone"`
+		Origins  []string `json:"cors_origins"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil { return nil, err }
+	out := make([]Tenant, 0, len(raw))
+	for _, r := range raw {
+		out = append(out, Tenant{ID: r.ID, Portal: r.Portal, Aliases: r.Aliases, AdsURL: r.AdsURL, Static: r.Static, Timezone: r.Timezone, Origins: r.Origins})
+	}
+	return out, nil
+}
@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+type mysqlSource struct{ db *sql.DB }
+
+// NewMySQLSource lê a tabela tenants:
+// id, portal, aliases (JSON: ["www.portal.com.br"]), ads_url, static, timezone,
+// cors_origins (JSON: ["https://*.portal.com.br"], opcional), active.
+func NewMySQLSource(db *sql.DB) Source { return &mysqlSource{db: db} }
+
+func (s *mysqlSource) Load(ctx context.Context) ([]Tenant, error) {
+	const q = `
+		SELECT id, portal, aliases, ads_url, static, COALESCE(timezone,''), cors_origins
+		FROM tenants
+		WHERE active = 1
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	out := make([]Tenant, 0, 8)
+	for rows.Next() {
+		var (
+			t           Tenant
+			aliasesJSON, originsJSON sql.NullString
+		)
+		if err := rows.Scan(&t.ID, &t.Portal, &aliasesJSON, &t.AdsURL, &t.Static, &t.Timezone, &originsJSON); err != nil {
+			return nil, err
+		}
+		if aliasesJSON.Valid && strings.TrimSpace(aliasesJSON.String) != "" {
+			if err := json.Unmarshal([]byte(aliasesJSON.String), &t.Aliases); err != nil {
+				return nil, err
+			}
+		}
+		if originsJSON.Valid && strings.TrimSpace(originsJSON.String) != "" {
+			if err := json.Unmarshal([]byte(originsJSON.String), &t.Origins); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -1,26 +1,208 @@
 package tenant
 
-import "strings"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/idna"
+)
 
 type Tenant struct {
-	ID     int
-	Portal string
-	AdsURL string
-	Static string
+	ID       int
+	Portal   string
+	Aliases  []string // hosts extras que resolvem para este tenant (ex.: www.)
+	AdsURL   string
+	Static   string
+	Timezone string // IANA, ex.: America/Sao_Paulo
+	Origins  []string // CORS; vazio = https:// do portal e dos aliases. Aceita "https://*.dominio"
+}
+
+// MatchOrigin compara uma origem com um padrão; "https://*.dominio" aceita qualquer subdomínio (não o apex).
+func MatchOrigin(pattern, origin string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(pattern), "/"))
+	origin = strings.ToLower(origin)
+	if pattern == origin { return true }
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok { return false }
+	oscheme, ohost, ok := strings.Cut(origin, "://")
+	return ok && oscheme == scheme && strings.HasSuffix(ohost, "."+host)
+}
+
+// AllowsOrigin diz se a origem bate com alguma das AllowedOrigins do tenant.
+func (t Tenant) AllowsOrigin(origin string) bool {
+	for _, p := range t.AllowedOrigins() {
+		if MatchOrigin(p, origin) { return true }
+	}
+	return false
+}
+
+// AllowedOrigins são as origens CORS do tenant (Origins ou, se vazio, derivadas do portal/aliases).
+func (t Tenant) AllowedOrigins() []string {
+	if len(t.Origins) > 0 { return t.Origins }
+	out := make([]string, 0, 1+len(t.Aliases))
+	for _, h := range append([]string{t.Portal}, t.Aliases...) {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" { out = append(out, "https://"+h) }
+	}
+	return out
 }
 
-var tenants = map[string]Tenant{
-	"conexaoguarulhos.com.br": {ID: 1, Portal: "conexaoguarulhos.com.br", AdsURL: "https://conexaoguarulhos.com.br/ads", Static: "https://static.conexaoguarulhos.com.br"},
-	"www.conexaoguarulhos.com.br": {ID: 1, Portal: "conexaoguarulhos.com.br", AdsURL: "https://conexaoguarulhos.com.br/ads", Static: "https://static.conexaoguarulhos.com.br"},
-	"gazetadeosasco.com.br": {ID: 2, Portal: "gazetadeosasco.com.br", AdsURL: "https://gazetadeosasco.com.br/ads", Static: "https://static.gazetadeosasco.com.br"},
-	"www.gazetadeosasco.com.br": {ID: 2, Portal: "gazetadeosasco.com.br", AdsURL: "https://gazetadeosasco.com.br/ads", Static: "https://static.gazetadeosasco.com.br"},
+// builtin: usados até o primeiro carregamento bem-sucedido (ou quando não há fonte configurada)
+var builtin = []Tenant{
+	{ID: 1, Portal: "conexaoguarulhos.com.br", Aliases: []string{"www.conexaoguarulhos.com.br"}, AdsURL: "https://conexaoguarulhos.com.br/ads", Static: "https://static.conexaoguarulhos.com.br", Timezone: "America/Sao_Paulo"},
+	{ID: 2, Portal: "gazetadeosasco.com.br", Aliases: []string{"www.gazetadeosasco.com.br"}, AdsURL: "https://gazetadeosasco.com.br/ads", Static: "https://static.gazetadeosasco.com.br", Timezone: "America/Sao_Paulo"},
 }
 
-var Default = Tenant{ID: 1, Portal: "conexaoguarulhos.com.br", AdsURL: "https://conexaoguarulhos.com.br/ads", Static: "https://static.conexaoguarulhos.com.br"}
+var Default = builtin[0]
+
+// registry é imutável; recarregar = montar outro e trocar o ponteiro (leitura sem lock no caminho quente).
+type registry struct {
+	byHost map[string]Tenant
+	wild   []wildHost // "*.dominio" — ordenado do sufixo mais longo para o mais curto
+	list   []Tenant   // por ID (inclui os só com host curinga)
+	ids    []int
+	def    Tenant
+}
+
+type wildHost struct {
+	suffix string // ".dominio"
+	t      Tenant
+}
+
+// ErrUnknownHost: o host não corresponde a nenhum tenant (ver Resolve).
+var ErrUnknownHost = errors.New("host não corresponde a nenhum tenant")
+
+var current atomic.Pointer[registry]
+
+func init() {
+	reg, _ := build(builtin)
+	current.Store(reg)
+}
 
+func build(list []Tenant) (*registry, error) {
+	if len(list) == 0 { return nil, errors.New("nenhum tenant") }
+	reg := &registry{byHost: map[string]Tenant{}}
+	for _, t := range list {
+		if t.ID <= 0 || strings.TrimSpace(t.Portal) == "" {
+			return nil, fmt.Errorf("tenant inválido: id=%d portal=%q", t.ID, t.Portal)
+		}
+		if slices.Contains(reg.ids, t.ID) { return nil, fmt.Errorf("tenant %d repetido", t.ID) }
+		if t.Timezone != "" {
+			if _, err := time.LoadLocation(t.Timezone); err != nil {
+				return nil, fmt.Errorf("tenant %d: timezone %q: %w", t.ID, t.Timezone, err)
+			}
+		}
+		for _, raw := range append([]string{t.Portal}, t.Aliases...) {
+			wild := strings.HasPrefix(strings.TrimSpace(raw), "*.")
+			h := NormalizeHost(strings.TrimPrefix(strings.TrimSpace(raw), "*."))
+			if h == "" { continue }
+			if wild {
+				reg.wild = append(reg.wild, wildHost{suffix: "." + h, t: t})
+				continue
+			}
+			if prev, dup := reg.byHost[h]; dup && prev.ID != t.ID {
+				return nil, fmt.Errorf("host %q em dois tenants (%d e %d)", h, prev.ID, t.ID)
+			}
+			reg.byHost[h] = t
+		}
+		reg.ids = append(reg.ids, t.ID)
+		reg.list = append(reg.list, t)
+	}
+	sort.Ints(reg.ids)
+	sort.Slice(reg.list, func(i, j int) bool { return reg.list[i].ID < reg.list[j].ID })
+	sort.SliceStable(reg.wild, func(i, j int) bool { return len(reg.wild[i].suffix) > len(reg.wild[j].suffix) })
+	// fallback: o Default se ainda existir, senão o menor ID
+	reg.def = list[0]
+	for _, t := range list {
+		if t.ID == Default.ID { reg.def = t; break }
+		if t.ID < reg.def.ID { reg.def = t }
+	}
+	return reg, nil
+}
+
+// NormalizeHost deixa o host no formato de comparação: sem porta, minúsculo, sem ponto final
+// e em ASCII (IDNA/punycode). Retorna "" se inválido.
+func NormalizeHost(h string) string {
+	h = strings.TrimSpace(h)
+	if i := strings.IndexByte(h, ','); i >= 0 { h = strings.TrimSpace(h[:i]) } // X-Forwarded-Host com vários hops
+	if hh, _, err := net.SplitHostPort(h); err == nil {
+		h = hh
+	} else if strings.HasPrefix(h, "[") && strings.HasSuffix(h, "]") {
+		h = h[1 : len(h)-1]
+	}
+	h = strings.TrimSuffix(strings.ToLower(h), ".")
+	if h == "" { return "" }
+	if net.ParseIP(h) != nil { return h }
+	a, err := idna.Lookup.ToASCII(h)
+	if err != nil { return "" }
+	return a
+}
+
+// Resolve encontra o tenant do host (forwarded tem prioridade). Sem fallback: host desconhecido = ErrUnknownHost.
+func Resolve(host, forwarded string) (Tenant, error) {
+	reg := current.Load()
+	raw := forwarded
+	if strings.TrimSpace(raw) == "" { raw = host }
+	h := NormalizeHost(raw)
+	if h == "" { return Tenant{}, fmt.Errorf("%w: %q", ErrUnknownHost, raw) }
+	if t, ok := reg.byHost[h]; ok { return t, nil }
+	for _, w := range reg.wild {
+		if strings.HasSuffix(h, w.suffix) { return w.t, nil }
+	}
+	return Tenant{}, fmt.Errorf("%w: %q", ErrUnknownHost, h)
+}
+
+// FromRequestHost é o Resolve com fallback para o tenant padrão (modo não estrito).
 func FromRequestHost(host, forwarded string) Tenant {
-	h := strings.ToLower(strings.TrimSpace(forwarded))
-	if h == "" { h = strings.ToLower(strings.TrimSpace(host)) }
-	if t, ok := tenants[h]; ok { return t }
-	return Default
+	if t, err := Resolve(host, forwarded); err == nil { return t }
+	return current.Load().def
+}
+
+// IDs retorna os IDs distintos dos tenants conhecidos, em ordem.
+func IDs() []int { return current.Load().ids }
+
+// All retorna os tenants carregados, em ordem de ID.
+func All() []Tenant { return slices.Clone(current.Load().list) }
+
+// Source carrega a lista de tenants (MySQL ou arquivo).
+type Source interface {
+	Load(ctx context.Context) ([]Tenant, error)
+}
+
+// Reload carrega da fonte, valida e troca o registro atomicamente. Em erro o registro atual é mantido.
+func Reload(ctx context.Context, src Source) (int, error) {
+	list, err := src.Load(ctx)
+	if err != nil { return 0, err }
+	reg, err := build(list)
+	if err != nil { return 0, err }
+	current.Store(reg)
+	return len(reg.ids), nil
+}
+
+// StartReloader recarrega a cada interval() (relido a cada volta; <= 0 pausa) até ctx acabar.
+func StartReloader(ctx context.Context, src Source, interval func() time.Duration, logger *slog.Logger) {
+	go func() {
+		for {
+			d := interval()
+			if d <= 0 { d = time.Minute } // desligado: só confere de novo depois
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+			if interval() <= 0 { continue }
+			if _, err := Reload(ctx, src); err != nil && logger != nil {
+				logger.Error("tenants: reload falhou (mantendo registro atual)", "err", err)
+			}
+		}
+	}()
 }
@@ -0,0 +1,159 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type listSource []Tenant
+
+func (s listSource) Load(context.Context) ([]Tenant, error) { return s, nil }
+
+// swap troca o registro global pelo do teste e o restaura no fim.
+func swap(t *testing.T, list []Tenant) error {
+	t.Helper()
+	old := current.Load()
+	t.Cleanup(func() { current.Store(old) })
+	_, err := Reload(context.Background(), listSource(list))
+	return err
+}
+
+func TestAllIncluiTenantSoCuringa(t *testing.T) {
+	err := swap(t, []Tenant{
+		{ID: 3, Portal: "*.blogs.example"},
+		{ID: 1, Portal: "portal.example", Aliases: []string{"www.portal.example"}},
+	})
+	if err != nil { t.Fatal(err) }
+	all := All()
+	if len(all) != 2 || all[0].ID != 1 || all[1].ID != 3 { t.Fatalf("All() = %+v, quer os tenants 1 e 3 em ordem", all) }
+	if got, err := Resolve("a.blogs.example", ""); err != nil || got.ID != 3 { t.Errorf("Resolve(a.blogs.example) = %d, %v; quer 3", got.ID, err) }
+
+	// a cópia devolvida não mexe no registro
+	all[0].Portal = "outro"
+	if All()[0].Portal != "portal.example" { t.Error("All() expõe o slice do registro") }
+}
+
+func TestBuildRecusa(t *testing.T) {
+	tests := []struct {
+		name string
+		list []Tenant
+		err  string
+	}{
+		{name: "vazio", list: nil, err: "nenhum tenant"},
+		{name: "ID repetido", list: []Tenant{{ID: 1, Portal: "a.example"}, {ID: 1, Portal: "b.example"}}, err: "tenant 1 repetido"},
+		{name: "host em dois tenants", list: []Tenant{{ID: 1, Portal: "a.example"}, {ID: 2, Portal: "b.example", Aliases: []string{"A.example"}}}, err: "em dois tenants"},
+		{name: "sem portal", list: []Tenant{{ID: 1}}, err: "tenant inválido"},
+		{name: "timezone inválido", list: []Tenant{{ID: 1, Portal: "a.example", Timezone: "Lua/Crateras"}}, err: "timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := build(tt.list)
+			if err == nil || !strings.Contains(err.Error(), tt.err) { t.Fatalf("err = %v, quer %q", err, tt.err) }
+		})
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"portal.example", "portal.example"},
+		{"  Portal.EXAMPLE.  ", "portal.example"},
+		{"portal.example:8080", "portal.example"},
+		{"portal.example.:443", "portal.example"},
+		{"127.0.0.1:80", "127.0.0.1"},
+		{"[2001:db8::1]:8080", "2001:db8::1"},
+		{"[::1]", "::1"},
+		{"::1", "::1"},
+		{"café.example", "xn--caf-dma.example"},
+		{"CAFÉ.Example:443", "xn--caf-dma.example"},
+		{"xn--caf-dma.example", "xn--caf-dma.example"},
+		{"a.example, proxy.internal", "a.example"}, // X-Forwarded-Host com vários hops: vale o primeiro
+		{"exa mple.example", ""},
+		{"under_score.example", ""},
+		{"-hifen.example", ""},
+		{"", ""},
+		{":8080", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeHost(tt.in); got != tt.want { t.Errorf("NormalizeHost(%q) = %q, quer %q", tt.in, got, tt.want) }
+	}
+}
+
+func TestResolveCuringa(t *testing.T) {
+	err := swap(t, []Tenant{
+		{ID: 1, Portal: "example.com", Aliases: []string{"*.example.com"}},
+		{ID: 2, Portal: "news.example.com", Aliases: []string{"*.news.example.com"}},
+		{ID: 3, Portal: "café.example", Aliases: []string{"*.café.example"}},
+	})
+	if err != nil { t.Fatal(err) }
+	tests := []struct {
+		host, fwd string
+		want      int // 0 = ErrUnknownHost
+	}{
+		{host: "example.com", want: 1},
+		{host: "www.example.com:8443", want: 1},
+		{host: "a.b.example.com", want: 1},
+		{host: "news.example.com", want: 2},     // host exato antes do curinga
+		{host: "sp.news.example.com", want: 2},  // sufixo mais longo ganha
+		{host: "badexample.com"},                // não é subdomínio
+		{host: "xn--caf-dma.example", want: 3},  // cadastrado em Unicode, pedido em punycode
+		{host: "Blog.Café.example.", want: 3},
+		{host: "outro.example", fwd: "www.example.com", want: 1}, // X-Forwarded-Host tem prioridade
+		{host: "", fwd: ""},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.host, tt.fwd)
+		if tt.want == 0 {
+			if err == nil { t.Errorf("Resolve(%q, %q) = %d, quer ErrUnknownHost", tt.host, tt.fwd, got.ID) }
+			continue
+		}
+		if err != nil || got.ID != tt.want { t.Errorf("Resolve(%q, %q) = %d, %v; quer %d", tt.host, tt.fwd, got.ID, err, tt.want) }
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern, origin string
+		want            bool
+	}{
+		{"https://portal.example", "https://portal.example", true},
+		{"https://portal.example/", "https://portal.example", true},
+		{" HTTPS://Portal.Example ", "https://PORTAL.example", true},
+		{"https://portal.example", "http://portal.example", false},
+		{"https://portal.example", "https://portal.example:8443", false},
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"https://*.example.com", "https://a.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false}, // apex fica de fora
+		{"https://*.example.com", "https://badexample.com", false},
+		{"https://*.example.com", "https://example.com.evil.io", false},
+		{"https://*.example.com", "http://a.example.com", false},
+		{"https://*.example.com", "https://a.example.com:8443", false},
+		{"https://*.example.com", "null", false},
+		{"https://*.example.com", "https://*.example.com", true},
+	}
+	for _, tt := range tests {
+		if got := MatchOrigin(tt.pattern, tt.origin); got != tt.want { t.Errorf("MatchOrigin(%q, %q) = %v, quer %v", tt.pattern, tt.origin, got, tt.want) }
+	}
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	derived := Tenant{ID: 1, Portal: "portal.example", Aliases: []string{"WWW.portal.example"}}
+	explicit := Tenant{ID: 2, Portal: "portal.example", Origins: []string{"https://*.portal.example", "http://localhost:3000"}}
+	tests := []struct {
+		t      Tenant
+		origin string
+		want   bool
+	}{
+		{derived, "https://portal.example", true},
+		{derived, "https://www.portal.example", true},
+		{derived, "http://portal.example", false},
+		{derived, "https://blog.portal.example", false},
+		{explicit, "https://blog.portal.example", true},
+		{explicit, "http://localhost:3000", true},
+		{explicit, "https://portal.example", false}, // Origins substitui as derivadas
+	}
+	for _, tt := range tests {
+		if got := tt.t.AllowsOrigin(tt.origin); got != tt.want { t.Errorf("tenant %d AllowsOrigin(%q) = %v, quer %v", tt.t.ID, tt.origin, got, tt.want) }
+	}
+}